@@ -0,0 +1,101 @@
+package commands
+
+import (
+	"context"
+	"fmt"
+	"path/filepath"
+	"strings"
+
+	"github.com/BurntSushi/toml"
+)
+
+func init() {
+	registerTool(&Tool{
+		Name:        "bckt_backups",
+		Title:       "Backups",
+		Description: "List or restore backups. Every time a post or the config is overwritten, the previous version is kept in the backup directory. Use action list to see backups (optionally for one path) and action restore with a backup id to put a version back.",
+		Annotations: writeTool,
+		Output:      BackupsOutput{},
+		Handler:     handler(HandleBcktBackups),
+	})
+}
+
+func HandleBcktBackups(ctx context.Context, id interface{}, args BackupsArgs, store *ConfigStore) *Response {
+	cfg := store.Get()
+
+	if args.Action == "restore" {
+		return restoreFromBackup(id, args, cfg, store)
+	}
+
+	original := ""
+	if args.Path != "" {
+		original = expandPath(args.Path)
+		if !filepath.IsAbs(original) && cfg.RootPath != "" {
+			original = filepath.Join(expandPath(cfg.RootPath), original)
+		}
+	}
+
+	backups, err := listBackups(cfg, original)
+	if err != nil {
+		return &Response{
+			JSONRPC: "2.0",
+			ID:      id,
+			Error:   &Error{Code: 1, Message: fmt.Sprintf("Failed to list backups: %v", err)},
+		}
+	}
+
+	var b strings.Builder
+	if len(backups) == 0 {
+		fmt.Fprintf(&b, "No backups found in %s\n", backupDir(cfg))
+	} else {
+		fmt.Fprintf(&b, "Backups in %s (newest first):\n", backupDir(cfg))
+		for _, backup := range backups {
+			fmt.Fprintf(&b, "\n%s  %s (%d bytes)\n  id: %s\n",
+				backup.Time.Local().Format("2006-01-02 15:04:05"), backup.Original, backup.Size, backup.ID)
+		}
+	}
+
+	content := []Content{
+		{Type: "text", Text: b.String()},
+	}
+
+	return &Response{
+		JSONRPC: "2.0",
+		ID:      id,
+		Result:  ToolCallResult{Content: content, StructuredContent: BackupsOutput{Dir: backupDir(cfg), Backups: backups}},
+	}
+}
+
+// restoreFromBackup restores the backup with id args.ID, for action restore.
+func restoreFromBackup(id interface{}, args BackupsArgs, cfg Config, store *ConfigStore) *Response {
+	if args.ID == "" {
+		return invalidArgument(id, "/id", "is required to restore a backup", "Call bckt_backups with action list to find the id")
+	}
+
+	restored, err := restoreBackup(cfg, args.ID)
+	if err != nil {
+		return &Response{
+			JSONRPC: "2.0",
+			ID:      id,
+			Error:   &Error{Code: 1, Message: fmt.Sprintf("Failed to restore backup: %v", err)},
+		}
+	}
+
+	// Pick up a restored config.toml right away
+	if restored == GlobalConfigPath() {
+		var restoredCfg Config
+		if _, err := toml.DecodeFile(restored, &restoredCfg); err == nil && restoredCfg.validate() == nil {
+			store.Replace(restoredCfg)
+		}
+	}
+
+	content := []Content{
+		{Type: "text", Text: fmt.Sprintf("✓ Restored %s from backup %s\nThe previous version was backed up first.", restored, args.ID)},
+	}
+
+	return &Response{
+		JSONRPC: "2.0",
+		ID:      id,
+		Result:  ToolCallResult{Content: content, StructuredContent: BackupsOutput{Dir: backupDir(cfg), Restored: restored}},
+	}
+}
@@ -0,0 +1,445 @@
+package commands
+
+import (
+	"archive/zip"
+	"context"
+	"encoding/json"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"runtime"
+	"sort"
+	"strings"
+	"sync"
+)
+
+// maxCompletions is the most values completion/complete may return.
+const maxCompletions = 100
+
+// Completer suggests values for an argument from what has been typed so
+// far, best match first.
+type Completer func(ctx context.Context, cfg Config, value string) ([]string, error)
+
+// HandleComplete answers completion/complete. Tool fields are completed with
+// the completers the tool registered, keyed by JSON pointer; "ref/tool" is
+// an extension, the spec only defines prompts and resource templates.
+// prompts maps prompt names to the completers of their arguments.
+func HandleComplete(ctx context.Context, id interface{}, params json.RawMessage, store *ConfigStore, prompts map[string]map[string]Completer) *Response {
+	var args CompleteParams
+	if err := json.Unmarshal(params, &args); err != nil || args.Argument.Name == "" {
+		return &Response{
+			JSONRPC: "2.0",
+			ID:      id,
+			Error:   &Error{Code: -32602, Message: "Invalid params: ref and argument.name are required"},
+		}
+	}
+
+	var complete Completer
+	switch args.Ref.Type {
+	case "ref/prompt":
+		arguments, ok := prompts[args.Ref.Name]
+		if !ok {
+			return &Response{
+				JSONRPC: "2.0",
+				ID:      id,
+				Error:   &Error{Code: -32602, Message: "Unknown prompt: " + args.Ref.Name},
+			}
+		}
+		complete = arguments[args.Argument.Name]
+	case "ref/tool":
+		tool := findTool(args.Ref.Name)
+		if tool == nil {
+			return &Response{
+				JSONRPC: "2.0",
+				ID:      id,
+				Error:   &Error{Code: -32602, Message: "Unknown tool: " + args.Ref.Name},
+			}
+		}
+		complete = tool.Complete[completionPointer(args.Argument.Name)]
+	case "ref/resource":
+		// Posts are concrete resources, there are no templates to complete
+	default:
+		return &Response{
+			JSONRPC: "2.0",
+			ID:      id,
+			Error:   &Error{Code: -32602, Message: "Invalid params: unsupported ref type " + args.Ref.Type},
+		}
+	}
+
+	values := []string{}
+	if complete != nil {
+		found, err := complete(ctx, store.Get(), args.Argument.Value)
+		if err != nil {
+			return &Response{
+				JSONRPC: "2.0",
+				ID:      id,
+				Error:   &Error{Code: -32603, Message: err.Error()},
+			}
+		}
+		values = append(values, found...)
+	}
+
+	completion := Completion{Values: values, Total: len(values)}
+	if len(values) > maxCompletions {
+		completion.Values = values[:maxCompletions]
+		completion.HasMore = true
+	}
+
+	return &Response{
+		JSONRPC: "2.0",
+		ID:      id,
+		Result:  CompleteResult{Completion: completion},
+	}
+}
+
+// completionPointer turns an argument name like "meta.tags" or "/meta/tags"
+// into the JSON pointer tools register completers under.
+func completionPointer(name string) string {
+	if strings.HasPrefix(name, "/") {
+		return name
+	}
+	return "/" + strings.ReplaceAll(name, ".", "/")
+}
+
+// CompleteTags suggests tags from the tag registry, most used first, or
+// the tags allowed by the front matter schema when it lists them.
+func CompleteTags(ctx context.Context, cfg Config, value string) ([]string, error) {
+	if field, ok := cfg.FrontMatter.Schema["tags"]; ok && len(field.Enum) > 0 {
+		return rankMatches(field.Enum, value), nil
+	}
+	registry, err := loadTagRegistry(ctx, cfg)
+	if err != nil {
+		return nil, err
+	}
+	return rankMatches(registry.tags, value), nil
+}
+
+// CompleteTagList completes the last tag of a comma-separated list, as
+// typed into a prompt argument, leaving out tags already in the list.
+func CompleteTagList(ctx context.Context, cfg Config, value string) ([]string, error) {
+	head, last := "", value
+	if i := strings.LastIndex(value, ","); i >= 0 {
+		head, last = value[:i+1]+" ", value[i+1:]
+	}
+
+	listed := map[string]bool{}
+	for _, tag := range strings.Split(head, ",") {
+		listed[tagKey(tag)] = true
+	}
+
+	tags, err := CompleteTags(ctx, cfg, strings.TrimSpace(last))
+	if err != nil {
+		return nil, err
+	}
+
+	var values []string
+	for _, tag := range tags {
+		if !listed[tagKey(tag)] {
+			values = append(values, head+tag)
+		}
+	}
+	return values, nil
+}
+
+// CompleteLangs suggests language codes: the ones allowed by the front
+// matter schema, or else the ones used by posts followed by ISO 639-1 codes.
+func CompleteLangs(ctx context.Context, cfg Config, value string) ([]string, error) {
+	if field, ok := cfg.FrontMatter.Schema["lang"]; ok && len(field.Enum) > 0 {
+		return rankMatches(field.Enum, value), nil
+	}
+
+	var used []string
+	if cfg.RootPath != "" {
+		posts, err := scanPosts(ctx, cfg)
+		if err != nil {
+			return nil, err
+		}
+		for _, post := range posts {
+			if lang, ok := post.FrontMatter["lang"].(string); ok && lang != "" {
+				used = append(used, lang)
+			}
+		}
+	}
+
+	langs := byFrequency(used)
+	seen := map[string]bool{}
+	for _, lang := range langs {
+		seen[strings.ToLower(lang)] = true
+	}
+	for _, code := range strings.Fields(isoLanguageCodes) {
+		if !seen[code] {
+			langs = append(langs, code)
+		}
+	}
+
+	return rankMatches(langs, value), nil
+}
+
+// CompleteSlugs suggests the slugs of existing posts and drafts, newest
+// first.
+func CompleteSlugs(ctx context.Context, cfg Config, value string) ([]string, error) {
+	return completeSlugs(ctx, cfg, value, scanAll)
+}
+
+// CompletePaths suggests the paths of existing posts and drafts relative to
+// root_path, newest first.
+func CompletePaths(ctx context.Context, cfg Config, value string) ([]string, error) {
+	return completePaths(ctx, cfg, value, scanAll)
+}
+
+// CompleteDraftSlugs suggests the slugs of drafts, newest first.
+func CompleteDraftSlugs(ctx context.Context, cfg Config, value string) ([]string, error) {
+	return completeSlugs(ctx, cfg, value, scanDrafts)
+}
+
+// CompleteDraftPaths suggests the paths of drafts relative to root_path,
+// newest first.
+func CompleteDraftPaths(ctx context.Context, cfg Config, value string) ([]string, error) {
+	return completePaths(ctx, cfg, value, scanDrafts)
+}
+
+func completeSlugs(ctx context.Context, cfg Config, value string, scan func(context.Context, Config) ([]*Post, error)) ([]string, error) {
+	if cfg.RootPath == "" {
+		return nil, nil
+	}
+	posts, err := scan(ctx, cfg)
+	if err != nil {
+		return nil, err
+	}
+
+	var slugs []string
+	seen := map[string]bool{}
+	for _, post := range posts {
+		if slug := post.Slug(); slug != "" && !seen[slug] {
+			seen[slug] = true
+			slugs = append(slugs, slug)
+		}
+	}
+	return rankMatches(slugs, value), nil
+}
+
+func completePaths(ctx context.Context, cfg Config, value string, scan func(context.Context, Config) ([]*Post, error)) ([]string, error) {
+	if cfg.RootPath == "" {
+		return nil, nil
+	}
+	posts, err := scan(ctx, cfg)
+	if err != nil {
+		return nil, err
+	}
+
+	paths := make([]string, 0, len(posts))
+	for _, post := range posts {
+		paths = append(paths, post.RelPath)
+	}
+	return rankMatches(paths, value), nil
+}
+
+// CompleteTimezones suggests IANA timezone names, matching the typed text
+// against the whole name or any part of it, e.g. "ath" finds Europe/Athens.
+func CompleteTimezones(ctx context.Context, cfg Config, value string) ([]string, error) {
+	names := timezoneNames()
+	if cfg.Timezone != "" {
+		// Offer the configured timezone first
+		names = append([]string{cfg.Timezone}, names...)
+	}
+	return rankMatches(dedupe(names), value), nil
+}
+
+// rankMatches filters candidates by the typed value, case-insensitively.
+// Prefix matches come first, then matches at the start of a word, then
+// matches anywhere; candidates keep their order within each group.
+func rankMatches(candidates []string, value string) []string {
+	value = strings.ToLower(strings.TrimSpace(value))
+	if value == "" {
+		return candidates
+	}
+
+	var prefix, word, anywhere []string
+	for _, candidate := range candidates {
+		lower := strings.ToLower(candidate)
+		i := strings.Index(lower, value)
+		switch {
+		case i < 0:
+			continue
+		case i == 0:
+			prefix = append(prefix, candidate)
+		case wordStart(lower, value):
+			word = append(word, candidate)
+		default:
+			anywhere = append(anywhere, candidate)
+		}
+	}
+	return append(append(prefix, word...), anywhere...)
+}
+
+// wordStart reports whether value occurs in s right after a separator.
+func wordStart(s, value string) bool {
+	for i := 1; i < len(s); i++ {
+		if strings.ContainsRune("/-_ .", rune(s[i-1])) && strings.HasPrefix(s[i:], value) {
+			return true
+		}
+	}
+	return false
+}
+
+// byFrequency returns the distinct values, most frequent first. Values that
+// differ only in case count together and use their most common spelling.
+func byFrequency(values []string) []string {
+	type entry struct {
+		total     int
+		spellings map[string]int
+		first     int
+	}
+	entries := map[string]*entry{}
+	for i, v := range values {
+		key := strings.ToLower(v)
+		e, ok := entries[key]
+		if !ok {
+			e = &entry{spellings: map[string]int{}, first: i}
+			entries[key] = e
+		}
+		e.total++
+		e.spellings[v]++
+	}
+
+	keys := make([]string, 0, len(entries))
+	for key := range entries {
+		keys = append(keys, key)
+	}
+	sort.Slice(keys, func(i, j int) bool {
+		a, b := entries[keys[i]], entries[keys[j]]
+		if a.total != b.total {
+			return a.total > b.total
+		}
+		return keys[i] < keys[j]
+	})
+
+	result := make([]string, 0, len(keys))
+	for _, key := range keys {
+		best, count := "", 0
+		for spelling, n := range entries[key].spellings {
+			if n > count || (n == count && spelling < best) {
+				best, count = spelling, n
+			}
+		}
+		result = append(result, best)
+	}
+	return result
+}
+
+func dedupe(values []string) []string {
+	seen := map[string]bool{}
+	result := values[:0:0]
+	for _, v := range values {
+		if !seen[v] {
+			seen[v] = true
+			result = append(result, v)
+		}
+	}
+	return result
+}
+
+var (
+	timezoneOnce  sync.Once
+	timezoneCache []string
+)
+
+// timezoneNames lists the zones in the tzdata database, looking in the
+// same places as time.LoadLocation. The list is read once.
+func timezoneNames() []string {
+	timezoneOnce.Do(func() {
+		var sources []string
+		if zoneinfo := os.Getenv("ZONEINFO"); zoneinfo != "" {
+			sources = append(sources, zoneinfo)
+		}
+		sources = append(sources,
+			"/usr/share/zoneinfo",
+			"/usr/share/lib/zoneinfo",
+			"/usr/lib/locale/TZ",
+			"/etc/zoneinfo",
+			filepath.Join(runtime.GOROOT(), "lib", "time", "zoneinfo.zip"),
+		)
+
+		for _, source := range sources {
+			if names := readZoneNames(source); len(names) > 0 {
+				timezoneCache = names
+				break
+			}
+		}
+		if !contains(timezoneCache, "UTC") {
+			timezoneCache = append(timezoneCache, "UTC")
+		}
+		sort.Strings(timezoneCache)
+	})
+	return timezoneCache
+}
+
+// readZoneNames lists the zone names in a zoneinfo directory or zip file.
+func readZoneNames(source string) []string {
+	var names []string
+
+	if strings.HasSuffix(source, ".zip") {
+		archive, err := zip.OpenReader(source)
+		if err != nil {
+			return nil
+		}
+		defer archive.Close()
+		for _, file := range archive.File {
+			if validZoneName(file.Name) {
+				names = append(names, file.Name)
+			}
+		}
+		return names
+	}
+
+	filepath.WalkDir(source, func(path string, d fs.DirEntry, err error) error {
+		if err != nil || d.IsDir() {
+			return nil
+		}
+		rel, err := filepath.Rel(source, path)
+		if err != nil {
+			return nil
+		}
+		name := filepath.ToSlash(rel)
+		if validZoneName(name) && isTZif(path) {
+			names = append(names, name)
+		}
+		return nil
+	})
+	return names
+}
+
+// validZoneName filters out the duplicate posix/ and right/ trees and the
+// non-zone files shipped with tzdata.
+func validZoneName(name string) bool {
+	if name == "" || name[0] < 'A' || name[0] > 'Z' {
+		return false
+	}
+	if strings.HasPrefix(name, "posix/") || strings.HasPrefix(name, "right/") {
+		return false
+	}
+	switch name {
+	case "Factory", "SECURITY":
+		return false
+	}
+	return !strings.ContainsAny(name, ". ")
+}
+
+func isTZif(path string) bool {
+	f, err := os.Open(path)
+	if err != nil {
+		return false
+	}
+	defer f.Close()
+	magic := make([]byte, 4)
+	n, _ := f.Read(magic)
+	return n == 4 && string(magic) == "TZif"
+}
+
+// isoLanguageCodes are the ISO 639-1 language codes.
+const isoLanguageCodes = `aa ab ae af ak am an ar as av ay az ba be bg bi bm bn bo br bs ca ce ch
+co cr cs cu cv cy da de dv dz ee el en eo es et eu fa ff fi fj fo fr fy ga gd gl gn gu gv ha he hi
+ho hr ht hu hy hz ia id ie ig ii ik io is it iu ja jv ka kg ki kj kk kl km kn ko kr ks ku kv kw ky
+la lb lg li ln lo lt lu lv mg mh mi mk ml mn mr ms mt my na nb nd ne ng nl nn no nr nv ny oc oj om
+or os pa pi pl ps pt qu rm rn ro ru rw sa sc sd se sg si sk sl sm sn so sq sr ss st su sv sw ta te
+tg th ti tk tl tn to tr ts tt tw ty ug uk ur uz ve vi vo wa wo xh yi yo za zh zu`
@@ -1,58 +1,70 @@
 package commands
 
 import (
+	"context"
 	"encoding/json"
 	"fmt"
-	"os"
-	"path/filepath"
 )
 
-func HandleBcktConfig(id interface{}, params ToolCallParams, globalConfig *Config) *Response {
-	var args struct {
-		RootPath    string `json:"root_path,omitempty"`
-		Timezone    string `json:"timezone,omitempty"`
-		PathPattern string `json:"path_pattern,omitempty"`
-		WrapAt      int    `json:"wrap_at,omitempty"`
-	}
-
-	if params.Arguments != nil {
-		if err := json.Unmarshal(*params.Arguments, &args); err != nil {
-			return &Response{
-				JSONRPC: "2.0",
-				ID:      id,
-				Error:   &Error{Code: -32602, Message: "Invalid arguments"},
-			}
-		}
-	}
-
-	// Ensure global config is loaded
-	if globalConfig == nil {
-		cfg := GetDefaultConfig()
-		globalConfig = &cfg
-	}
+func init() {
+	registerTool(&Tool{
+		Name:        "bckt_config",
+		Title:       "Configuration",
+		Description: "Update the bckt-mcp configuration and save it. If no parameters are provided, returns the current config (bckt_config_view does the same without write access).",
+		Annotations: idempotentWriteTool,
+		Output:      ConfigOutput{},
+		Handler:     handler(HandleBcktConfig),
+		Complete: map[string]Completer{
+			"/timezone": CompleteTimezones,
+		},
+	})
+	registerTool(&Tool{
+		Name:        "bckt_config_view",
+		Title:       "View Configuration",
+		Description: "Show the current bckt-mcp configuration without changing it: root_path, timezone, path_pattern, wrap_at and front matter rules.",
+		Annotations: readOnlyTool,
+		Output:      ConfigOutput{},
+		Handler:     handler(HandleBcktConfigView),
+	})
+}
 
+func HandleBcktConfig(ctx context.Context, id interface{}, args ConfigArgs, store *ConfigStore) *Response {
 	// Check if this is a view or update operation
-	isUpdate := args.RootPath != "" || args.Timezone != "" || args.PathPattern != "" || args.WrapAt != 0
+	isUpdate := args.RootPath != "" || args.Timezone != "" || args.PathPattern != "" || args.DraftsPattern != "" || args.ScheduledPattern != "" || args.WrapAt != 0
 
 	if isUpdate {
-		// Update config
-		if args.RootPath != "" {
-			globalConfig.RootPath = expandPath(args.RootPath)
-		}
-		if args.Timezone != "" {
-			globalConfig.Timezone = args.Timezone
-		}
-		if args.PathPattern != "" {
-			globalConfig.PathPattern = args.PathPattern
-		}
-		if args.WrapAt != 0 {
-			globalConfig.MarkdownRule.WrapAt = args.WrapAt
+		// Update config and save it to file
+		err := store.Update(func(cfg *Config) error {
+			if args.RootPath != "" {
+				cfg.RootPath = expandPath(args.RootPath)
+			}
+			if args.Timezone != "" {
+				cfg.Timezone = args.Timezone
+			}
+			if args.PathPattern != "" {
+				cfg.PathPattern = args.PathPattern
+			}
+			if args.DraftsPattern != "" {
+				cfg.DraftsPattern = args.DraftsPattern
+			}
+			if args.ScheduledPattern != "" {
+				cfg.ScheduledPattern = args.ScheduledPattern
+			}
+			if args.WrapAt != 0 {
+				cfg.MarkdownRule.WrapAt = args.WrapAt
+			}
+			if verr := cfg.checkPatterns(); verr != nil {
+				return verr
+			}
+			if verr := cfg.validate(); verr != nil {
+				return verr
+			}
+			return SaveGlobalConfig(GlobalConfigPath(), cfg)
+		})
+		if verr, ok := err.(*ValidationError); ok {
+			return validationFailed(id, verr)
 		}
-
-		// Save to file
-		homeDir, _ := os.UserHomeDir()
-		configPath := filepath.Join(homeDir, ".config", "bckt-mcp", "config.toml")
-		if err := SaveGlobalConfig(configPath, globalConfig); err != nil {
+		if err != nil {
 			return &Response{
 				JSONRPC: "2.0",
 				ID:      id,
@@ -60,18 +72,31 @@ func HandleBcktConfig(id interface{}, params ToolCallParams, globalConfig *Confi
 			}
 		}
 
+		output := configOutput(store.Get())
 		resultText := "✓ Configuration updated:\n"
 		if args.RootPath != "" {
 			resultText += fmt.Sprintf("  root_path: %s\n", args.RootPath)
+			output.Updated = append(output.Updated, "root_path")
 		}
 		if args.Timezone != "" {
 			resultText += fmt.Sprintf("  timezone: %s\n", args.Timezone)
+			output.Updated = append(output.Updated, "timezone")
 		}
 		if args.PathPattern != "" {
 			resultText += fmt.Sprintf("  path_pattern: %s\n", args.PathPattern)
+			output.Updated = append(output.Updated, "path_pattern")
+		}
+		if args.DraftsPattern != "" {
+			resultText += fmt.Sprintf("  drafts_pattern: %s\n", args.DraftsPattern)
+			output.Updated = append(output.Updated, "drafts_pattern")
+		}
+		if args.ScheduledPattern != "" {
+			resultText += fmt.Sprintf("  scheduled_pattern: %s\n", args.ScheduledPattern)
+			output.Updated = append(output.Updated, "scheduled_pattern")
 		}
 		if args.WrapAt != 0 {
 			resultText += fmt.Sprintf("  wrap_at: %d\n", args.WrapAt)
+			output.Updated = append(output.Updated, "wrap_at")
 		}
 
 		content := []Content{
@@ -81,13 +106,20 @@ func HandleBcktConfig(id interface{}, params ToolCallParams, globalConfig *Confi
 		return &Response{
 			JSONRPC: "2.0",
 			ID:      id,
-			Result:  ToolCallResult{Content: content},
+			Result:  ToolCallResult{Content: content, StructuredContent: output},
 		}
 	}
 
-	// View current config
-	homeDir, _ := os.UserHomeDir()
-	configPath := filepath.Join(homeDir, ".config", "bckt-mcp", "config.toml")
+	return HandleBcktConfigView(ctx, id, struct{}{}, store)
+}
+
+// HandleBcktConfigView shows the current configuration. It never writes, so
+// clients can call it without asking.
+func HandleBcktConfigView(ctx context.Context, id interface{}, args struct{}, store *ConfigStore) *Response {
+	cfg := store.Get()
+	configPath := GlobalConfigPath()
+	// As JSON, since auto is a pointer that %v would print as an address
+	schema, _ := json.Marshal(cfg.FrontMatter.Schema)
 
 	configText := fmt.Sprintf(`Current Configuration:
 Config file: %s
@@ -95,19 +127,25 @@ Config file: %s
 root_path: %s
 timezone: %s
 path_pattern: %s
+drafts_pattern: %s
+scheduled_pattern: %s
 wrap_at: %d
 
 Front Matter:
   required: %v
   defaults: %v
+  schema: %s
 `,
 		configPath,
-		globalConfig.RootPath,
-		globalConfig.Timezone,
-		globalConfig.PathPattern,
-		globalConfig.MarkdownRule.WrapAt,
-		globalConfig.FrontMatter.Required,
-		globalConfig.FrontMatter.Defaults,
+		cfg.RootPath,
+		cfg.Timezone,
+		cfg.PathPattern,
+		cfg.draftsPattern(),
+		cfg.scheduledPattern(),
+		cfg.MarkdownRule.WrapAt,
+		cfg.FrontMatter.Required,
+		cfg.FrontMatter.Defaults,
+		schema,
 	)
 
 	content := []Content{
@@ -117,6 +155,29 @@ Front Matter:
 	return &Response{
 		JSONRPC: "2.0",
 		ID:      id,
-		Result:  ToolCallResult{Content: content},
+		Result:  ToolCallResult{Content: content, StructuredContent: configOutput(cfg)},
+	}
+}
+
+// configOutput is the structured form of the settings shown by bckt_config.
+func configOutput(cfg Config) ConfigOutput {
+	output := ConfigOutput{
+		ConfigPath:       GlobalConfigPath(),
+		RootPath:         cfg.RootPath,
+		Timezone:         cfg.Timezone,
+		PathPattern:      cfg.PathPattern,
+		DraftsPattern:    cfg.draftsPattern(),
+		ScheduledPattern: cfg.scheduledPattern(),
+		WrapAt:           cfg.MarkdownRule.WrapAt,
+	}
+	output.FrontMatter.Required = cfg.FrontMatter.Required
+	output.FrontMatter.Defaults = cfg.FrontMatter.Defaults
+	output.FrontMatter.Schema = cfg.FrontMatter.Schema
+	if output.FrontMatter.Required == nil {
+		output.FrontMatter.Required = []string{}
+	}
+	if output.FrontMatter.Defaults == nil {
+		output.FrontMatter.Defaults = map[string]interface{}{}
 	}
+	return output
 }
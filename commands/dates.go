@@ -0,0 +1,293 @@
+package commands
+
+import (
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// dateLayout is the format dates are written in.
+const dateLayout = "2006-01-02 15:04:05 -0700"
+
+// dateHint shows the kinds of dates parseDate understands.
+const dateHint = `Use a date like "2025-10-07", "2025-10-07 09:30", "Oct 7, 2025", "tomorrow at 9am" or "3 days ago"`
+
+// Layouts with a time of day, tried before anything else. The ones without
+// an offset are taken in the configured timezone.
+var dateTimeLayouts = []string{
+	dateLayout,
+	time.RFC3339Nano,
+	"2006-01-02T15:04:05-0700",
+	"2006-01-02 15:04:05Z07:00",
+	"2006-01-02 15:04 -0700",
+	"Mon 2 Jan 2006 15:04:05 -0700", // RFC 1123 with the comma removed
+	"2 Jan 2006 15:04:05 -0700",
+	"2006-01-02 15:04:05",
+	"2006-01-02T15:04:05",
+	"2006-01-02 15:04",
+	"2006-01-02T15:04",
+}
+
+// Layouts for a calendar day, used alone or before a time of day. Commas
+// and ordinal suffixes are removed before matching.
+var dayLayouts = []string{
+	"2006-1-2",
+	"2006/1/2",
+	"Jan 2 2006",
+	"January 2 2006",
+	"2 Jan 2006",
+	"2 January 2006",
+	"Mon Jan 2 2006",
+	"Monday January 2 2006",
+	"Mon 2 Jan 2006",
+	"Monday 2 January 2006",
+}
+
+// Layouts for a day in the current year.
+var yearlessDayLayouts = []string{
+	"Jan 2",
+	"January 2",
+	"2 Jan",
+	"2 January",
+}
+
+var (
+	ordinalSuffix = regexp.MustCompile(`\b(\d{1,2})(st|nd|rd|th)\b`)
+	clockPattern  = regexp.MustCompile(`^(\d{1,2})(?::(\d{2}))?(?::(\d{2}))?\s*(am|pm)?$`)
+)
+
+// parseDate understands the dates people and assistants write: bckt's own
+// format, RFC 3339, date-only and written-out dates, optionally followed
+// by a time of day, and relative dates like "yesterday", "in 2 days" or
+// "next friday at 9am". Dates without an offset, and relative dates, are
+// in loc.
+func parseDate(value string, loc *time.Location, now time.Time) (time.Time, error) {
+	s := cleanDate(value)
+	if s == "" {
+		return time.Time{}, fmt.Errorf("date is empty")
+	}
+	now = now.In(loc)
+
+	if t, ok := parseAbsoluteDate(s, loc); ok {
+		return t, nil
+	}
+
+	// Split off a time of day: "... at 9:30", or a trailing "9:30" or "9am"
+	dayPart := strings.ToLower(s)
+	var clock time.Duration
+	hasClock := false
+	if i := strings.LastIndex(dayPart, " at "); i >= 0 {
+		c, ok := parseClock(dayPart[i+len(" at "):], true)
+		if !ok {
+			return time.Time{}, fmt.Errorf("cannot understand the time in %q", value)
+		}
+		dayPart, clock, hasClock = dayPart[:i], c, true
+	} else if fields := strings.Fields(dayPart); len(fields) > 0 {
+		for n := 1; n <= 2 && n <= len(fields); n++ {
+			if c, ok := parseClock(strings.Join(fields[len(fields)-n:], " "), false); ok {
+				dayPart, clock, hasClock = strings.Join(fields[:len(fields)-n], " "), c, true
+				break
+			}
+		}
+	}
+
+	day, exact, ok := parseDay(dayPart, loc, now)
+	if !ok {
+		return time.Time{}, fmt.Errorf("cannot understand date %q", value)
+	}
+	if !hasClock {
+		return day, nil
+	}
+	if exact {
+		return time.Time{}, fmt.Errorf("cannot understand date %q: it already names a time, leave out the time of day", value)
+	}
+	y, m, d := day.Date()
+	return time.Date(y, m, d, 0, 0, 0, 0, loc).Add(clock), nil
+}
+
+// parseDateValue parses a front matter date as written by bckt, YAML or
+// an editor. Relative dates aren't accepted here, as their meaning changes
+// over time; dates without an offset are in loc, the configured timezone.
+func parseDateValue(value interface{}, loc *time.Location) (time.Time, bool) {
+	switch v := value.(type) {
+	case time.Time:
+		return v, true
+	case string:
+		return parseAbsoluteDate(cleanDate(v), loc)
+	}
+	return time.Time{}, false
+}
+
+// parseAbsoluteDate parses a cleaned date that names a calendar day, with
+// or without a time of day.
+func parseAbsoluteDate(s string, loc *time.Location) (time.Time, bool) {
+	for _, layout := range dateTimeLayouts {
+		if t, err := time.ParseInLocation(layout, s, loc); err == nil {
+			return t, true
+		}
+	}
+	for _, layout := range dayLayouts {
+		if t, err := time.ParseInLocation(layout, s, loc); err == nil {
+			return t, true
+		}
+	}
+	return time.Time{}, false
+}
+
+// cleanDate drops commas, ordinal suffixes and repeated spaces, so "Oct 7th,
+// 2025" reads as "Oct 7 2025".
+func cleanDate(value string) string {
+	s := strings.Join(strings.Fields(strings.ReplaceAll(value, ",", " ")), " ")
+	return ordinalSuffix.ReplaceAllString(s, "$1")
+}
+
+// parseDay parses the day part of a date, lowercased. exact is set for
+// dates that already name a time of day, like "now" or "2 hours ago".
+// Relative days keep the current time of day; calendar days start at
+// midnight.
+func parseDay(s string, loc *time.Location, now time.Time) (day time.Time, exact, ok bool) {
+	if s == "" {
+		return now, false, true // Just a time of day, so today
+	}
+
+	if t, ok := parseAbsoluteDate(s, loc); ok {
+		return t, false, true
+	}
+	for _, layout := range yearlessDayLayouts {
+		if t, err := time.ParseInLocation(layout, s, loc); err == nil {
+			return t.AddDate(now.Year()-t.Year(), 0, 0), false, true
+		}
+	}
+
+	fields := strings.Fields(s)
+	switch {
+	case s == "now":
+		return now, true, true
+	case s == "today":
+		return now, false, true
+	case s == "tomorrow":
+		return now.AddDate(0, 0, 1), false, true
+	case s == "yesterday":
+		return now.AddDate(0, 0, -1), false, true
+
+	case len(fields) == 3 && fields[2] == "ago":
+		return addPeriod(now, fields[0], fields[1], -1)
+	case len(fields) == 3 && fields[0] == "in":
+		return addPeriod(now, fields[1], fields[2], 1)
+
+	case len(fields) == 2 && (fields[0] == "next" || fields[0] == "last" || fields[0] == "this"):
+		if weekday, ok := parseWeekday(fields[1]); ok {
+			return weekdayFrom(now, weekday, fields[0]), false, true
+		}
+		sign := map[string]int{"next": 1, "last": -1, "this": 0}[fields[0]]
+		return addPeriod(now, strconv.Itoa(sign), fields[1], 1)
+
+	case len(fields) == 1:
+		if weekday, ok := parseWeekday(fields[0]); ok {
+			return weekdayFrom(now, weekday, "this"), false, true
+		}
+	}
+
+	return time.Time{}, false, false
+}
+
+// addPeriod adds count units to now, in the direction of sign.
+func addPeriod(now time.Time, count, unit string, sign int) (time.Time, bool, bool) {
+	n, ok := parseCount(count)
+	if !ok {
+		return time.Time{}, false, false
+	}
+	n *= sign
+
+	switch strings.TrimSuffix(unit, "s") {
+	case "minute", "min":
+		return now.Add(time.Duration(n) * time.Minute), true, true
+	case "hour", "hr":
+		return now.Add(time.Duration(n) * time.Hour), true, true
+	case "day":
+		return now.AddDate(0, 0, n), false, true
+	case "week":
+		return now.AddDate(0, 0, 7*n), false, true
+	case "month":
+		return now.AddDate(0, n, 0), false, true
+	case "year":
+		return now.AddDate(n, 0, 0), false, true
+	}
+	return time.Time{}, false, false
+}
+
+func parseCount(s string) (int, bool) {
+	words := map[string]int{
+		"a": 1, "an": 1, "one": 1, "two": 2, "three": 3, "four": 4, "five": 5, "six": 6,
+		"seven": 7, "eight": 8, "nine": 9, "ten": 10, "eleven": 11, "twelve": 12,
+	}
+	if n, ok := words[s]; ok {
+		return n, true
+	}
+	n, err := strconv.Atoi(s)
+	return n, err == nil
+}
+
+func parseWeekday(s string) (time.Weekday, bool) {
+	for day := time.Sunday; day <= time.Saturday; day++ {
+		name := strings.ToLower(day.String())
+		if s == name || s == name[:3] {
+			return day, true
+		}
+	}
+	return 0, false
+}
+
+// weekdayFrom finds a weekday relative to now: "this" is the coming one,
+// today included; "next" is the coming one after today; "last" the
+// previous one before today.
+func weekdayFrom(now time.Time, weekday time.Weekday, which string) time.Time {
+	ahead := (int(weekday) - int(now.Weekday()) + 7) % 7
+	switch which {
+	case "next":
+		if ahead == 0 {
+			ahead = 7
+		}
+	case "last":
+		ahead -= 7
+	}
+	return now.AddDate(0, 0, ahead)
+}
+
+// parseClock parses a time of day like "9:30", "17:00", "9am", "9:30 pm",
+// "noon" or "midnight", returned as the time since midnight. A bare hour
+// like "9" is only accepted after "at".
+func parseClock(s string, bareHour bool) (time.Duration, bool) {
+	switch s {
+	case "noon":
+		return 12 * time.Hour, true
+	case "midnight":
+		return 0, true
+	}
+
+	m := clockPattern.FindStringSubmatch(strings.TrimSpace(s))
+	if m == nil || (m[2] == "" && m[4] == "" && !bareHour) {
+		return 0, false
+	}
+
+	hour, _ := strconv.Atoi(m[1])
+	minute, _ := strconv.Atoi(m[2])
+	second, _ := strconv.Atoi(m[3])
+	switch m[4] {
+	case "am", "pm":
+		if hour < 1 || hour > 12 {
+			return 0, false
+		}
+		hour %= 12
+		if m[4] == "pm" {
+			hour += 12
+		}
+	}
+	if hour > 23 || minute > 59 || second > 59 {
+		return 0, false
+	}
+
+	return time.Duration(hour)*time.Hour + time.Duration(minute)*time.Minute + time.Duration(second)*time.Second, true
+}
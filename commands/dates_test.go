@@ -0,0 +1,125 @@
+package commands
+
+import (
+	"testing"
+	"time"
+)
+
+func TestParseDate(t *testing.T) {
+	loc, err := time.LoadLocation("Europe/Athens")
+	if err != nil {
+		t.Skip("no timezone data:", err)
+	}
+	// A Tuesday afternoon
+	now := time.Date(2025, 10, 7, 14, 0, 0, 0, loc)
+
+	tests := []struct {
+		value string
+		want  string // In dateLayout, empty when value is refused
+	}{
+		// Absolute dates
+		{"2025-10-07 09:30:00 +0300", "2025-10-07 09:30:00 +0300"},
+		{"2025-10-07T09:30:00Z", "2025-10-07 09:30:00 +0000"},
+		{"2025-10-07T09:30:00+05:30", "2025-10-07 09:30:00 +0530"},
+		{"2025-10-07", "2025-10-07 00:00:00 +0300"},
+		{"2025-10-07 09:30", "2025-10-07 09:30:00 +0300"},
+		{"2025-12-01 10:00", "2025-12-01 10:00:00 +0200"},
+		{"2025/10/7 9:30pm", "2025-10-07 21:30:00 +0300"},
+		{"Oct 7th, 2025", "2025-10-07 00:00:00 +0300"},
+		{"  Oct   7 ,  2025 ", "2025-10-07 00:00:00 +0300"},
+		{"Tuesday, 7 October 2025 at 17:00", "2025-10-07 17:00:00 +0300"},
+		{"Oct 8", "2025-10-08 00:00:00 +0300"},
+		{"1 January", "2025-01-01 00:00:00 +0200"},
+
+		// Relative dates
+		{"now", "2025-10-07 14:00:00 +0300"},
+		{"today", "2025-10-07 14:00:00 +0300"},
+		{"Today at 9", "2025-10-07 09:00:00 +0300"},
+		{"17:00", "2025-10-07 17:00:00 +0300"},
+		{"tomorrow at 9am", "2025-10-08 09:00:00 +0300"},
+		{"tomorrow 9:30 pm", "2025-10-08 21:30:00 +0300"},
+		{"yesterday", "2025-10-06 14:00:00 +0300"},
+		{"3 days ago", "2025-10-04 14:00:00 +0300"},
+		{"in 2 weeks", "2025-10-21 14:00:00 +0300"},
+		{"an hour ago", "2025-10-07 13:00:00 +0300"},
+		{"in 30 minutes", "2025-10-07 14:30:00 +0300"},
+		{"last month", "2025-09-07 14:00:00 +0300"},
+		{"next year", "2026-10-07 14:00:00 +0300"},
+		{"tuesday", "2025-10-07 14:00:00 +0300"},
+		{"next tuesday", "2025-10-14 14:00:00 +0300"},
+		{"last tuesday", "2025-09-30 14:00:00 +0300"},
+		{"next friday", "2025-10-10 14:00:00 +0300"},
+		{"friday at noon", "2025-10-10 12:00:00 +0300"},
+		{"sat at midnight", "2025-10-11 00:00:00 +0300"},
+		{"in 2 months at 12:30am", "2025-12-07 00:30:00 +0200"},
+
+		// Refused
+		{"", ""},
+		{"someday", ""},
+		{"2025-13-01", ""},
+		{"tomorrow at 25:00", ""},
+		{"tomorrow at 13pm", ""},
+		{"now at 9am", ""},
+		{"2 hours ago at 9", ""},
+		{"in many days", ""},
+		{"next fortnight", ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.value, func(t *testing.T) {
+			got, err := parseDate(tt.value, loc, now)
+			if tt.want == "" {
+				if err == nil {
+					t.Errorf("parseDate(%q) = %s, want an error", tt.value, got.Format(dateLayout))
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("parseDate(%q) error = %v", tt.value, err)
+			}
+			if s := got.Format(dateLayout); s != tt.want {
+				t.Errorf("parseDate(%q) = %s, want %s", tt.value, s, tt.want)
+			}
+		})
+	}
+}
+
+func TestParseDateValue(t *testing.T) {
+	loc, err := time.LoadLocation("Europe/Athens")
+	if err != nil {
+		t.Skip("no timezone data:", err)
+	}
+	written := time.Date(2025, 10, 7, 9, 30, 0, 0, time.UTC)
+
+	tests := []struct {
+		name  string
+		value interface{}
+		want  string // In dateLayout, empty when value is refused
+	}{
+		{"bckt format", "2025-10-07 09:30:00 +0300", "2025-10-07 09:30:00 +0300"},
+		{"no offset", "2025-10-07 09:30", "2025-10-07 09:30:00 +0300"},
+		{"day", "Oct 7, 2025", "2025-10-07 00:00:00 +0300"},
+		{"YAML timestamp", written, "2025-10-07 09:30:00 +0000"},
+		{"relative", "tomorrow", ""},
+		{"number", 20251007, ""},
+		{"missing", nil, ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, ok := parseDateValue(tt.value, loc)
+			if tt.want == "" {
+				if ok {
+					t.Errorf("parseDateValue(%v) = %s, want it refused", tt.value, got.Format(dateLayout))
+				}
+				return
+			}
+			if !ok {
+				t.Fatalf("parseDateValue(%v) refused it", tt.value)
+			}
+			if s := got.Format(dateLayout); s != tt.want {
+				t.Errorf("parseDateValue(%v) = %s, want %s", tt.value, s, tt.want)
+			}
+		})
+	}
+}
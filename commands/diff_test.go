@@ -0,0 +1,91 @@
+package commands
+
+import (
+	"strconv"
+	"strings"
+	"testing"
+)
+
+func TestUnifiedDiff(t *testing.T) {
+	// numbered returns the lines 1 to n, with the given lines replaced or,
+	// when replaced by "", removed
+	numbered := func(n int, replace map[int]string) string {
+		var b strings.Builder
+		for i := 1; i <= n; i++ {
+			if line, ok := replace[i]; ok {
+				if line != "" {
+					b.WriteString(line + "\n")
+				}
+				continue
+			}
+			b.WriteString(strconv.Itoa(i) + "\n")
+		}
+		return b.String()
+	}
+
+	tests := []struct {
+		name     string
+		from, to string
+		want     string
+	}{
+		{
+			"equal",
+			"a\nb\n", "a\nb\n",
+			"",
+		},
+		{
+			"change in the middle",
+			numbered(10, nil), numbered(10, map[int]string{5: "five"}),
+			"--- old\n+++ new\n@@ -2,7 +2,7 @@\n 2\n 3\n 4\n-5\n+five\n 6\n 7\n 8\n",
+		},
+		{
+			"change at the start",
+			numbered(5, nil), numbered(5, map[int]string{1: "one"}),
+			"--- old\n+++ new\n@@ -1,4 +1,4 @@\n-1\n+one\n 2\n 3\n 4\n",
+		},
+		{
+			"lines added at the end",
+			"a\nb\n", "a\nb\nc\nd\n",
+			"--- old\n+++ new\n@@ -1,2 +1,4 @@\n a\n b\n+c\n+d\n",
+		},
+		{
+			"lines removed",
+			numbered(8, nil), numbered(8, map[int]string{4: "", 5: ""}),
+			"--- old\n+++ new\n@@ -1,8 +1,6 @@\n 1\n 2\n 3\n-4\n-5\n 6\n 7\n 8\n",
+		},
+		{
+			"from empty",
+			"", "a\n",
+			"--- old\n+++ new\n@@ -0,0 +1,1 @@\n+a\n",
+		},
+		{
+			"to empty",
+			"a\nb\n", "",
+			"--- old\n+++ new\n@@ -1,2 +0,0 @@\n-a\n-b\n",
+		},
+		{
+			"close changes share a hunk",
+			numbered(12, nil), numbered(12, map[int]string{3: "three", 9: "nine"}),
+			"--- old\n+++ new\n@@ -1,12 +1,12 @@\n 1\n 2\n-3\n+three\n 4\n 5\n 6\n 7\n 8\n-9\n+nine\n 10\n 11\n 12\n",
+		},
+		{
+			"far changes get two hunks",
+			numbered(20, nil), numbered(20, map[int]string{2: "two", 18: "eighteen"}),
+			"--- old\n+++ new\n@@ -1,5 +1,5 @@\n 1\n-2\n+two\n 3\n 4\n 5\n" +
+				"@@ -15,6 +15,6 @@\n 15\n 16\n 17\n-18\n+eighteen\n 19\n 20\n",
+		},
+		{
+			"missing final newline",
+			"a\nb", "a\nc",
+			"--- old\n+++ new\n@@ -1,2 +1,2 @@\n a\n-b\n+c\n",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := unifiedDiff("old", "new", tt.from, tt.to); got != tt.want {
+				t.Errorf("unifiedDiff() =\n%s\nwant\n%s", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,367 @@
+package commands
+
+import (
+	"fmt"
+	"net/url"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+)
+
+const backupTimeFormat = "20060102T150405.000000000Z"
+
+// Backup is a saved copy of a file taken before it was overwritten.
+type Backup struct {
+	ID       string    `json:"id"`
+	Original string    `json:"original"`
+	Time     time.Time `json:"time"`
+	Size     int64     `json:"size"`
+}
+
+// writeFileAtomic writes data to a temporary file next to path and renames
+// it into place, so readers never see a half-written file. A file that
+// already exists keeps its permissions; perm is for new files.
+func writeFileAtomic(path string, data []byte, perm os.FileMode) error {
+	if info, err := os.Stat(path); err == nil {
+		perm = info.Mode().Perm()
+	}
+	tmpPath, err := writeTemp(path, data, perm)
+	if err != nil {
+		return err
+	}
+	if err := os.Rename(tmpPath, path); err != nil {
+		os.Remove(tmpPath)
+		return err
+	}
+	return nil
+}
+
+// writeFileExclusive is writeFileAtomic for new files: it fails with an
+// error for which os.IsExist is true when path already exists, even when
+// another request created it a moment ago.
+func writeFileExclusive(path string, data []byte, perm os.FileMode) error {
+	tmpPath, err := writeTemp(path, data, perm)
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmpPath)
+
+	err = os.Link(tmpPath, path)
+	if err == nil || os.IsExist(err) {
+		return err
+	}
+
+	// No hard links on this file system: still refuse to replace a file
+	f, err := os.OpenFile(path, os.O_WRONLY|os.O_CREATE|os.O_EXCL, perm)
+	if err != nil {
+		return err
+	}
+	if _, err := f.Write(data); err != nil {
+		f.Close()
+		os.Remove(path)
+		return err
+	}
+	return f.Close()
+}
+
+// writeTemp writes data to a new temporary file next to path and returns
+// its name.
+func writeTemp(path string, data []byte, perm os.FileMode) (string, error) {
+	tmp, err := os.CreateTemp(filepath.Dir(path), "."+filepath.Base(path)+".tmp-*")
+	if err != nil {
+		return "", err
+	}
+	tmpPath := tmp.Name()
+
+	cleanup := func(err error) (string, error) {
+		tmp.Close()
+		os.Remove(tmpPath)
+		return "", err
+	}
+
+	if _, err := tmp.Write(data); err != nil {
+		return cleanup(err)
+	}
+	if err := tmp.Sync(); err != nil {
+		return cleanup(err)
+	}
+	if err := tmp.Chmod(perm); err != nil {
+		return cleanup(err)
+	}
+	if err := tmp.Close(); err != nil {
+		return cleanup(err)
+	}
+	return tmpPath, nil
+}
+
+// confinePath returns the absolute path a post write to path goes to,
+// refusing anything that lands outside root_path once symlinks are
+// resolved. Relative paths are taken from root_path; absolute paths may
+// also point into the [save] allowed_dirs.
+func confinePath(cfg Config, path string) (string, error) {
+	root := rootDir(cfg)
+
+	target := filepath.Clean(expandPath(path))
+	if !filepath.IsAbs(target) {
+		if root == "" {
+			return "", fmt.Errorf("root_path is not configured, so the relative path %s has nowhere to go", path)
+		}
+		target = filepath.Join(root, target)
+	}
+
+	resolved, err := resolvePath(target)
+	if err != nil {
+		return "", err
+	}
+
+	var sandboxes []string
+	if root != "" {
+		sandboxes = append(sandboxes, root)
+	}
+	if filepath.IsAbs(expandPath(path)) {
+		sandboxes = append(sandboxes, cfg.Save.AllowedDirs...)
+	}
+	for _, dir := range sandboxes {
+		dir, err := resolvePath(expandPath(dir))
+		if err == nil && filepath.IsAbs(dir) && within(dir, resolved) {
+			return target, nil
+		}
+	}
+
+	if root == "" {
+		return "", fmt.Errorf("refusing to write %s: root_path is not configured and the path is not in [save] allowed_dirs", resolved)
+	}
+	if resolved != target {
+		return "", fmt.Errorf("refusing to write %s: it resolves to %s, outside root_path %s", target, resolved, root)
+	}
+	return "", fmt.Errorf("refusing to write %s: it is outside root_path %s", resolved, root)
+}
+
+// rootDir returns root_path expanded and made absolute, or "" when it
+// isn't configured.
+func rootDir(cfg Config) string {
+	root := expandPath(cfg.RootPath)
+	if root == "" {
+		return ""
+	}
+	if abs, err := filepath.Abs(root); err == nil {
+		return abs
+	}
+	return root
+}
+
+// resolvePath follows the symlinks in path. Parts that don't exist yet are
+// kept as they are, since they will be created as plain directories.
+func resolvePath(path string) (string, error) {
+	missing := ""
+	for dir := path; ; dir = filepath.Dir(dir) {
+		resolved, err := filepath.EvalSymlinks(dir)
+		if err == nil {
+			return filepath.Join(resolved, missing), nil
+		}
+		if _, lerr := os.Lstat(dir); lerr == nil {
+			return "", fmt.Errorf("cannot resolve %s: %v", dir, err) // A dangling symlink
+		}
+		if filepath.Dir(dir) == dir {
+			return path, nil
+		}
+		missing = filepath.Join(filepath.Base(dir), missing)
+	}
+}
+
+// within reports whether path is strictly inside dir.
+func within(dir, path string) bool {
+	rel, err := filepath.Rel(dir, path)
+	return err == nil && rel != "." && rel != ".." && !strings.HasPrefix(rel, ".."+string(filepath.Separator))
+}
+
+// writePost writes a post like writeFileWithBackup, refusing paths that
+// confinePath doesn't allow.
+func writePost(cfg Config, path string, data []byte) error {
+	if _, err := confinePath(cfg, path); err != nil {
+		return err
+	}
+	return writeFileWithBackup(cfg, path, data)
+}
+
+// createPost writes a new post, refusing paths that confinePath doesn't
+// allow and, with an os.IsExist error, paths where a file already exists.
+func createPost(cfg Config, path string, data []byte) error {
+	if _, err := confinePath(cfg, path); err != nil {
+		return err
+	}
+	defer forgetPostTags()
+	return writeFileExclusive(path, data, 0644)
+}
+
+// writeFileWithBackup backs up the current contents of path, if any, and
+// then writes data atomically.
+func writeFileWithBackup(cfg Config, path string, data []byte) error {
+	if _, err := backupFile(cfg, path); err != nil {
+		return fmt.Errorf("failed to back up %s: %v", path, err)
+	}
+	defer forgetPostTags()
+	return writeFileAtomic(path, data, 0644)
+}
+
+func backupDir(cfg Config) string {
+	if cfg.Backup.Dir != "" {
+		return expandPath(cfg.Backup.Dir)
+	}
+	return filepath.Join(filepath.Dir(GlobalConfigPath()), "backups")
+}
+
+func backupKeep(cfg Config) int {
+	if cfg.Backup.Keep > 0 {
+		return cfg.Backup.Keep
+	}
+	return GetDefaultConfig().Backup.Keep
+}
+
+// backupFile copies path into the backup directory and prunes old backups
+// of the same file. It does nothing when path does not exist.
+func backupFile(cfg Config, path string) (string, error) {
+	data, err := os.ReadFile(path)
+	if os.IsNotExist(err) {
+		return "", nil
+	}
+	if err != nil {
+		return "", err
+	}
+
+	absPath, err := filepath.Abs(path)
+	if err != nil {
+		return "", err
+	}
+
+	dir := filepath.Join(backupDir(cfg), url.PathEscape(absPath))
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return "", err
+	}
+
+	name := time.Now().UTC().Format(backupTimeFormat) + ".bak"
+	if err := writeFileAtomic(filepath.Join(dir, name), data, 0644); err != nil {
+		return "", err
+	}
+
+	// Keep only the newest backups of this file
+	names, err := backupNames(dir)
+	if err != nil {
+		return "", err
+	}
+	for i := backupKeep(cfg); i < len(names); i++ {
+		os.Remove(filepath.Join(dir, names[i]))
+	}
+
+	return filepath.Base(dir) + "/" + name, nil
+}
+
+// backupNames lists the backup files in dir, newest first.
+func backupNames(dir string) ([]string, error) {
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return nil, err
+	}
+
+	var names []string
+	for _, entry := range entries {
+		if !entry.IsDir() && strings.HasSuffix(entry.Name(), ".bak") {
+			names = append(names, entry.Name())
+		}
+	}
+	sort.Sort(sort.Reverse(sort.StringSlice(names)))
+	return names, nil
+}
+
+// listBackups returns all backups, newest first. When original is set only
+// backups of that file are returned.
+func listBackups(cfg Config, original string) ([]Backup, error) {
+	root := backupDir(cfg)
+	entries, err := os.ReadDir(root)
+	if os.IsNotExist(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	var backups []Backup
+	for _, entry := range entries {
+		if !entry.IsDir() {
+			continue
+		}
+		path, err := url.PathUnescape(entry.Name())
+		if err != nil {
+			continue
+		}
+		if original != "" && path != original {
+			continue
+		}
+
+		names, err := backupNames(filepath.Join(root, entry.Name()))
+		if err != nil {
+			continue
+		}
+		for _, name := range names {
+			t, err := time.Parse(backupTimeFormat, strings.TrimSuffix(name, ".bak"))
+			if err != nil {
+				continue
+			}
+			var size int64
+			if info, err := os.Stat(filepath.Join(root, entry.Name(), name)); err == nil {
+				size = info.Size()
+			}
+			backups = append(backups, Backup{
+				ID:       entry.Name() + "/" + name,
+				Original: path,
+				Time:     t,
+				Size:     size,
+			})
+		}
+	}
+
+	sort.SliceStable(backups, func(i, j int) bool {
+		return backups[i].Time.After(backups[j].Time)
+	})
+	return backups, nil
+}
+
+// restoreBackup writes a backup back to its original location. The current
+// file is backed up first so a restore can itself be undone.
+func restoreBackup(cfg Config, id string) (string, error) {
+	parts := strings.Split(id, "/")
+	if len(parts) != 2 || parts[0] == "" || parts[0] == "." || parts[0] == ".." || !strings.HasSuffix(parts[1], ".bak") {
+		return "", fmt.Errorf("invalid backup id: %s", id)
+	}
+
+	original, err := url.PathUnescape(parts[0])
+	if err != nil || !filepath.IsAbs(original) {
+		return "", fmt.Errorf("invalid backup id: %s", id)
+	}
+
+	// Backups are made of config.toml and of posts, so a restore may only
+	// write where bckt_save could
+	if original != GlobalConfigPath() {
+		if original, err = confinePath(cfg, original); err != nil {
+			return "", err
+		}
+	}
+
+	data, err := os.ReadFile(filepath.Join(backupDir(cfg), parts[0], parts[1]))
+	if err != nil {
+		if os.IsNotExist(err) {
+			return "", fmt.Errorf("backup not found: %s", id)
+		}
+		return "", err
+	}
+
+	if err := os.MkdirAll(filepath.Dir(original), 0755); err != nil {
+		return "", err
+	}
+	if err := writeFileWithBackup(cfg, original, data); err != nil {
+		return "", err
+	}
+	return original, nil
+}
@@ -0,0 +1,195 @@
+package commands
+
+import (
+	"net/url"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestConfinePath(t *testing.T) {
+	base := t.TempDir()
+	root := filepath.Join(base, "blog")
+	outside := filepath.Join(base, "outside")
+	allowed := filepath.Join(base, "allowed")
+	for _, dir := range []string{filepath.Join(root, "posts"), outside, allowed} {
+		if err := os.MkdirAll(dir, 0755); err != nil {
+			t.Fatal(err)
+		}
+	}
+	links := map[string]string{
+		filepath.Join(root, "posts", "out"):    outside,
+		filepath.Join(root, "posts", "in"):     filepath.Join(root, "posts"),
+		filepath.Join(root, "posts", "broken"): filepath.Join(base, "missing"),
+	}
+	for link, target := range links {
+		if err := os.Symlink(target, link); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	tests := []struct {
+		name    string
+		path    string
+		allowed []string
+		want    string // Empty when the path is refused
+	}{
+		{"relative", "posts/a/a.md", nil, filepath.Join(root, "posts", "a", "a.md")},
+		{"absolute inside", filepath.Join(root, "posts", "a.md"), nil, filepath.Join(root, "posts", "a.md")},
+		{"dot dot inside", "posts/x/../a.md", nil, filepath.Join(root, "posts", "a.md")},
+		{"dot dot outside", "../outside/a.md", nil, ""},
+		{"dot dot deep", "posts/../../../etc/passwd", nil, ""},
+		{"absolute outside", filepath.Join(outside, "a.md"), nil, ""},
+		{"root itself", ".", nil, ""},
+		{"symlink outside", "posts/out/a.md", nil, ""},
+		{"symlink inside", "posts/in/a.md", nil, filepath.Join(root, "posts", "in", "a.md")},
+		{"dangling symlink", "posts/broken/a.md", nil, ""},
+		{"allowed dir", filepath.Join(allowed, "a.md"), []string{allowed}, filepath.Join(allowed, "a.md")},
+		{"allowed dir itself", allowed, []string{allowed}, ""},
+		{"allowed dir, relative path", "../allowed/a.md", []string{allowed}, ""},
+		{"other dir with allowed_dirs", filepath.Join(outside, "a.md"), []string{allowed}, ""},
+		{"symlink into allowed dir", "posts/out/a.md", []string{outside}, ""},
+		{"absolute symlink into allowed dir", filepath.Join(root, "posts", "out", "a.md"), []string{outside}, filepath.Join(root, "posts", "out", "a.md")},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cfg := GetDefaultConfig()
+			cfg.RootPath = root
+			cfg.Save.AllowedDirs = tt.allowed
+
+			got, err := confinePath(cfg, tt.path)
+			if tt.want == "" {
+				if err == nil {
+					t.Fatalf("confinePath(%q) = %q, want an error", tt.path, got)
+				}
+				if !strings.HasPrefix(err.Error(), "refusing to write") && !strings.HasPrefix(err.Error(), "cannot resolve") {
+					t.Errorf("confinePath(%q) error = %v", tt.path, err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("confinePath(%q) error = %v", tt.path, err)
+			}
+			if got != tt.want {
+				t.Errorf("confinePath(%q) = %q, want %q", tt.path, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestConfinePathWithoutRoot(t *testing.T) {
+	allowed := t.TempDir()
+
+	tests := []struct {
+		name    string
+		path    string
+		allowed []string
+		wantErr bool
+	}{
+		{"relative", "posts/a.md", nil, true},
+		{"absolute", filepath.Join(allowed, "a.md"), nil, true},
+		{"allowed dir", filepath.Join(allowed, "a.md"), []string{allowed}, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cfg := GetDefaultConfig()
+			cfg.Save.AllowedDirs = tt.allowed
+
+			_, err := confinePath(cfg, tt.path)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("confinePath(%q) error = %v, want error %v", tt.path, err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestRestoreBackup(t *testing.T) {
+	store, root := newTestBlog(t)
+	cfg := store.Get()
+	outside := filepath.Join(filepath.Dir(root), "outside.md")
+
+	// A backup id names the file it restores, so one can be made up
+	plant := func(original string) string {
+		dir := filepath.Join(backupDir(cfg), url.PathEscape(original))
+		if err := os.MkdirAll(dir, 0755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(filepath.Join(dir, "20251007T093000.000000000Z.bak"), []byte("restored\n"), 0644); err != nil {
+			t.Fatal(err)
+		}
+		return url.PathEscape(original) + "/20251007T093000.000000000Z.bak"
+	}
+
+	tests := []struct {
+		name     string
+		original string
+		wantErr  bool
+	}{
+		{"post", filepath.Join(root, "posts/a.md"), false},
+		{"config", GlobalConfigPath(), false},
+		{"outside root_path", outside, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			restored, err := restoreBackup(cfg, plant(tt.original))
+			if tt.wantErr {
+				if err == nil {
+					t.Fatalf("restored %s, want an error", restored)
+				}
+				if _, err := os.Stat(tt.original); !os.IsNotExist(err) {
+					t.Errorf("%s was written (%v)", tt.original, err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got := readTestFile(t, tt.original); got != "restored\n" {
+				t.Errorf("%s holds %q", tt.original, got)
+			}
+		})
+	}
+}
+
+func TestWriteFileAtomicMode(t *testing.T) {
+	dir := t.TempDir()
+
+	tests := []struct {
+		name     string
+		existing os.FileMode // 0 for a new file
+		want     os.FileMode
+	}{
+		{"new file", 0, 0644},
+		{"private file", 0600, 0600},
+		{"group writable", 0664, 0664},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			path := filepath.Join(dir, strings.ReplaceAll(tt.name, " ", "-")+".md")
+			if tt.existing != 0 {
+				if err := os.WriteFile(path, []byte("old\n"), tt.existing); err != nil {
+					t.Fatal(err)
+				}
+				if err := os.Chmod(path, tt.existing); err != nil {
+					t.Fatal(err)
+				}
+			}
+
+			if err := writeFileAtomic(path, []byte("new\n"), 0644); err != nil {
+				t.Fatal(err)
+			}
+			info, err := os.Stat(path)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got := info.Mode().Perm(); got != tt.want {
+				t.Errorf("mode = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
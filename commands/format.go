@@ -1,24 +1,51 @@
 package commands
 
 import (
-	"encoding/json"
+	"context"
 	"fmt"
 	"strings"
 )
 
-func HandleBcktFormat(id interface{}, params ToolCallParams, previewMode bool, globalConfig *Config) *Response {
-	var input FormatInput
-	if params.Arguments != nil {
-		if err := json.Unmarshal(*params.Arguments, &input); err != nil {
-			return &Response{
-				JSONRPC: "2.0",
-				ID:      id,
-				Error:   &Error{Code: -32602, Message: "Invalid arguments"},
-			}
-		}
-	}
+func init() {
+	registerTool(&Tool{
+		Name:        "bckt",
+		Title:       "Format Blog Post",
+		Description: "Format raw text and metadata into bckt-compatible Markdown with YAML front matter and compute file path. IMPORTANT: Before calling this tool, you MUST ask the user to provide: title, tags, abstract, and optionally slug, language, and excerpt. Never auto-generate metadata without explicit user confirmation.",
+		Annotations: readOnlyTool,
+		Output:      FormatOutput{},
+		Handler:     handler(HandleBcktFormat),
+		Complete: map[string]Completer{
+			"/meta/tags": CompleteTags,
+			"/meta/lang": CompleteLangs,
+		},
+	})
+	registerTool(&Tool{
+		Name:        "bckt_preview",
+		Title:       "Preview Blog Post",
+		Description: "Preview the formatted output without saving. Shows the generated YAML front matter, markdown, and computed file path.",
+		Annotations: readOnlyTool,
+		Output:      FormatOutput{},
+		Handler:     handler(HandleBcktPreview),
+		Complete: map[string]Completer{
+			"/meta/tags": CompleteTags,
+			"/meta/lang": CompleteLangs,
+		},
+	})
+}
+
+func HandleBcktFormat(ctx context.Context, id interface{}, input FormatInput, store *ConfigStore) *Response {
+	return formatPost(ctx, id, input, false, store)
+}
 
-	output, err := FormatContent(input, globalConfig)
+func HandleBcktPreview(ctx context.Context, id interface{}, input FormatInput, store *ConfigStore) *Response {
+	return formatPost(ctx, id, input, true, store)
+}
+
+func formatPost(ctx context.Context, id interface{}, input FormatInput, previewMode bool, store *ConfigStore) *Response {
+	output, err := FormatContent(ctx, input, store.Get())
+	if verr, ok := err.(*ValidationError); ok {
+		return validationFailed(id, verr)
+	}
 	if err != nil {
 		return &Response{
 			JSONRPC: "2.0",
@@ -42,9 +69,13 @@ func HandleBcktFormat(id interface{}, params ToolCallParams, previewMode bool, g
 	content = append(content, Content{Type: "text", Text: fmt.Sprintf("Path: %s", output.Path)})
 	content = append(content, Content{Type: "text", Text: output.Markdown})
 
+	if output.Warnings == nil {
+		output.Warnings = []string{}
+	}
+
 	return &Response{
 		JSONRPC: "2.0",
 		ID:      id,
-		Result:  ToolCallResult{Content: content},
+		Result:  ToolCallResult{Content: content, StructuredContent: output},
 	}
 }
@@ -0,0 +1,259 @@
+package commands
+
+import (
+	"fmt"
+	"math"
+	"reflect"
+	"sort"
+	"strings"
+	"time"
+)
+
+// schemaProvider is implemented by types that describe their own JSON
+// Schema instead of having it derived from their Go type.
+type schemaProvider interface {
+	JSONSchema() map[string]interface{}
+}
+
+var (
+	schemaProviderType = reflect.TypeOf((*schemaProvider)(nil)).Elem()
+	timeType           = reflect.TypeOf(time.Time{})
+)
+
+// schemaFor derives a JSON Schema from a Go value. Struct fields use their
+// json name and are required unless tagged omitempty, and other keys are
+// not allowed; a description tag
+// documents the field and an enum tag lists allowed values, e.g.
+//
+//	Sort string `json:"sort,omitempty" enum:"date_desc,date_asc" description:"Sort order"`
+func schemaFor(v interface{}) map[string]interface{} {
+	return typeSchema(reflect.TypeOf(v))
+}
+
+func typeSchema(t reflect.Type) map[string]interface{} {
+	if t.Implements(schemaProviderType) {
+		return reflect.Zero(t).Interface().(schemaProvider).JSONSchema()
+	}
+	if t == timeType {
+		return map[string]interface{}{"type": "string", "format": "date-time"}
+	}
+
+	switch t.Kind() {
+	case reflect.Ptr:
+		return typeSchema(t.Elem())
+	case reflect.String:
+		return map[string]interface{}{"type": "string"}
+	case reflect.Bool:
+		return map[string]interface{}{"type": "boolean"}
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return map[string]interface{}{"type": "integer"}
+	case reflect.Float32, reflect.Float64:
+		return map[string]interface{}{"type": "number"}
+	case reflect.Slice, reflect.Array:
+		return map[string]interface{}{"type": "array", "items": typeSchema(t.Elem())}
+	case reflect.Map:
+		schema := map[string]interface{}{"type": "object"}
+		if t.Elem().Kind() != reflect.Interface {
+			schema["additionalProperties"] = typeSchema(t.Elem())
+		}
+		return schema
+	case reflect.Struct:
+		return structSchema(t)
+	default:
+		return map[string]interface{}{} // Any value
+	}
+}
+
+func structSchema(t reflect.Type) map[string]interface{} {
+	properties := map[string]interface{}{}
+	required := []string{}
+
+	for i := 0; i < t.NumField(); i++ {
+		field := t.Field(i)
+		if field.PkgPath != "" {
+			continue // Unexported
+		}
+		name, omitempty := jsonName(field)
+		if name == "-" {
+			continue
+		}
+
+		schema := typeSchema(field.Type)
+		if description := field.Tag.Get("description"); description != "" {
+			schema["description"] = description
+		}
+		if enum := field.Tag.Get("enum"); enum != "" {
+			schema["enum"] = strings.Split(enum, ",")
+		}
+		properties[name] = schema
+		if !omitempty {
+			required = append(required, name)
+		}
+	}
+
+	schema := map[string]interface{}{"type": "object", "properties": properties, "additionalProperties": false}
+	if len(required) > 0 {
+		schema["required"] = required
+	}
+	return schema
+}
+
+// jsonName returns the name encoding/json uses for a field and whether it
+// is tagged omitempty.
+func jsonName(field reflect.StructField) (string, bool) {
+	name, options, _ := strings.Cut(field.Tag.Get("json"), ",")
+	if name == "" {
+		name = field.Name
+	}
+	return name, strings.Contains(","+options+",", ",omitempty,")
+}
+
+// validateArguments checks decoded JSON arguments against a schema made by
+// schemaFor, collecting every problem.
+func validateArguments(value interface{}, schema map[string]interface{}) *ValidationError {
+	verr := &ValidationError{}
+	validateValue(verr, "", value, schema)
+	if len(verr.Errors) == 0 {
+		return nil
+	}
+	return verr
+}
+
+func validateValue(verr *ValidationError, pointer string, value interface{}, schema map[string]interface{}) {
+	want, _ := schema["type"].(string)
+	if want != "" && !hasJSONType(value, want) {
+		fe := verr.typeError(pointer, want, value)
+		fe.Hint = "Send a JSON " + want
+		if description, ok := schema["description"].(string); ok {
+			fe.Hint += ": " + description
+		}
+		return
+	}
+
+	if enum, ok := schema["enum"].([]string); ok {
+		s, _ := value.(string)
+		if !contains(enum, s) {
+			verr.add(pointer, "must be one of %s, got %q", strings.Join(enum, ", "), s).Hint =
+				"Use one of: " + strings.Join(enum, ", ")
+		}
+	}
+
+	switch v := value.(type) {
+	case map[string]interface{}:
+		properties, _ := schema["properties"].(map[string]interface{})
+		required, _ := schema["required"].([]string)
+		for _, name := range required {
+			if _, ok := v[name]; !ok {
+				fe := verr.add(pointerJoin(pointer, name), "is required")
+				fe.Hint = "Add " + name
+				if sub, ok := properties[name].(map[string]interface{}); ok {
+					fe.Expected, _ = sub["type"].(string)
+					if description, ok := sub["description"].(string); ok {
+						fe.Hint += ": " + description
+					}
+				}
+			}
+		}
+
+		extra, _ := schema["additionalProperties"].(map[string]interface{})
+		closed := schema["additionalProperties"] == false
+		keys := make([]string, 0, len(v))
+		for k := range v {
+			keys = append(keys, k)
+		}
+		sort.Strings(keys)
+		for _, k := range keys {
+			if v[k] == nil && !contains(required, k) {
+				continue // null leaves an optional field unset, as with encoding/json
+			}
+			if sub, ok := properties[k].(map[string]interface{}); ok {
+				validateValue(verr, pointerJoin(pointer, k), v[k], sub)
+			} else if extra != nil {
+				validateValue(verr, pointerJoin(pointer, k), v[k], extra)
+			} else if closed {
+				verr.add(pointerJoin(pointer, k), "unknown argument").Hint = unknownArgumentHint(k, properties)
+			}
+		}
+	case []interface{}:
+		if items, ok := schema["items"].(map[string]interface{}); ok {
+			for i, item := range v {
+				validateValue(verr, pointerJoin(pointer, fmt.Sprint(i)), item, items)
+			}
+		}
+	}
+}
+
+// unknownArgumentHint suggests the property closest to an unknown key, or
+// lists them all when none is close.
+func unknownArgumentHint(key string, properties map[string]interface{}) string {
+	names := make([]string, 0, len(properties))
+	for name := range properties {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	if len(names) == 0 {
+		return "Remove it, this tool takes no arguments"
+	}
+
+	best, bestDistance := "", 0
+	for _, name := range names {
+		if d := editDistance(strings.ToLower(key), strings.ToLower(name)); best == "" || d < bestDistance {
+			best, bestDistance = name, d
+		}
+	}
+	// Allow about one typo per three letters
+	if bestDistance <= 1+len(best)/3 {
+		return fmt.Sprintf("Did you mean %q?", best)
+	}
+	return "Remove it, or use one of: " + strings.Join(names, ", ")
+}
+
+// editDistance is the Levenshtein distance between a and b.
+func editDistance(a, b string) int {
+	ra, rb := []rune(a), []rune(b)
+	prev := make([]int, len(rb)+1)
+	for j := range prev {
+		prev[j] = j
+	}
+	for i := 1; i <= len(ra); i++ {
+		cur := make([]int, len(rb)+1)
+		cur[0] = i
+		for j := 1; j <= len(rb); j++ {
+			cost := 1
+			if ra[i-1] == rb[j-1] {
+				cost = 0
+			}
+			cur[j] = min(prev[j]+1, cur[j-1]+1, prev[j-1]+cost)
+		}
+		prev = cur
+	}
+	return prev[len(rb)]
+}
+
+// hasJSONType reports whether a value decoded by encoding/json has the
+// given JSON Schema type.
+func hasJSONType(value interface{}, want string) bool {
+	switch v := value.(type) {
+	case string:
+		return want == "string"
+	case bool:
+		return want == "boolean"
+	case float64:
+		return want == "number" || (want == "integer" && v == math.Trunc(v))
+	case []interface{}:
+		return want == "array"
+	case map[string]interface{}:
+		return want == "object"
+	}
+	return false
+}
+
+func contains(list []string, s string) bool {
+	for _, item := range list {
+		if item == s {
+			return true
+		}
+	}
+	return false
+}
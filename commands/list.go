@@ -0,0 +1,274 @@
+package commands
+
+import (
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+func init() {
+	registerTool(&Tool{
+		Name:        "bckt_list_posts",
+		Title:       "List Posts",
+		Description: "List existing posts under root_path, filtered by date range, tag, language and title. Results are sorted and paginated; pass nextCursor back as cursor to get the next page.",
+		Annotations: readOnlyTool,
+		Output:      ListPostsOutput{},
+		Handler:     handler(HandleBcktListPosts),
+		Complete: map[string]Completer{
+			"/tag":  CompleteTags,
+			"/lang": CompleteLangs,
+		},
+	})
+	registerTool(&Tool{
+		Name:        "bckt_list_drafts",
+		Title:       "List Drafts",
+		Description: "List drafts: posts saved under drafts_pattern or flagged with draft: true. Takes the same filters, sorting and paging as bckt_list_posts. Publish a draft with bckt_publish.",
+		Annotations: readOnlyTool,
+		Output:      ListPostsOutput{},
+		Handler:     handler(HandleBcktListDrafts),
+		Complete: map[string]Completer{
+			"/tag":  CompleteTags,
+			"/lang": CompleteLangs,
+		},
+	})
+}
+
+const (
+	defaultListLimit = 20
+	maxListLimit     = 100
+)
+
+// PostSummary is the structured form of a post returned by bckt_list_posts.
+type PostSummary struct {
+	URI      string   `json:"uri"`
+	Path     string   `json:"path"`
+	Title    string   `json:"title"`
+	Slug     string   `json:"slug"`
+	Date     string   `json:"date"`
+	Tags     []string `json:"tags"`
+	Lang     string   `json:"lang,omitempty"`
+	Abstract string   `json:"abstract,omitempty"`
+}
+
+type ListPostsOutput struct {
+	Posts      []PostSummary `json:"posts"`
+	Total      int           `json:"total" description:"Number of posts matching the filters"`
+	NextCursor string        `json:"nextCursor,omitempty" description:"Pass as cursor to get the next page"`
+}
+
+func HandleBcktListPosts(ctx context.Context, id interface{}, args ListPostsArgs, store *ConfigStore) *Response {
+	return listPosts(ctx, id, args, store, false)
+}
+
+func HandleBcktListDrafts(ctx context.Context, id interface{}, args ListPostsArgs, store *ConfigStore) *Response {
+	return listPosts(ctx, id, args, store, true)
+}
+
+func listPosts(ctx context.Context, id interface{}, args ListPostsArgs, store *ConfigStore, drafts bool) *Response {
+	cfg := store.Get()
+
+	// Parse filters. They compare whole days in the configured timezone
+	loc := cfg.location()
+	var from, to string
+	if args.From != "" {
+		date, err := parseDate(args.From, loc, time.Now())
+		if err != nil {
+			return invalidArgument(id, "/from", err.Error(), dateHint)
+		}
+		from = date.In(loc).Format("2006-01-02")
+	}
+	if args.To != "" {
+		date, err := parseDate(args.To, loc, time.Now())
+		if err != nil {
+			return invalidArgument(id, "/to", err.Error(), dateHint)
+		}
+		to = date.In(loc).Format("2006-01-02")
+	}
+
+	offset := 0
+	var err error
+	if args.Cursor != "" {
+		if offset, err = decodeCursor(args.Cursor); err != nil {
+			return invalidArgument(id, "/cursor", "invalid cursor", "Pass nextCursor from the previous result unchanged, or leave cursor out to start over")
+		}
+	}
+
+	limit := args.Limit
+	if limit <= 0 {
+		limit = defaultListLimit
+	}
+	if limit > maxListLimit {
+		limit = maxListLimit
+	}
+
+	scan, noun := scanPosts, "posts"
+	if drafts {
+		scan, noun = scanDrafts, "drafts"
+	}
+	posts, err := scan(ctx, cfg)
+	if err != nil {
+		return &Response{
+			JSONRPC: "2.0",
+			ID:      id,
+			Error:   &Error{Code: 1, Message: err.Error()},
+		}
+	}
+
+	// Filter
+	var matched []*Post
+	for _, post := range posts {
+		date, hasDate := post.Date(loc)
+		day := date.In(loc).Format("2006-01-02")
+		if from != "" && (!hasDate || day < from) {
+			continue
+		}
+		if to != "" && (!hasDate || day > to) {
+			continue
+		}
+		if args.Tag != "" && !hasTag(post, args.Tag) {
+			continue
+		}
+		if args.Lang != "" {
+			if lang, _ := post.FrontMatter["lang"].(string); !strings.EqualFold(lang, args.Lang) {
+				continue
+			}
+		}
+		if args.Title != "" && !strings.Contains(strings.ToLower(post.Title()), strings.ToLower(args.Title)) {
+			continue
+		}
+		matched = append(matched, post)
+	}
+
+	// Sort
+	switch args.Sort {
+	case "", "date_desc":
+		sort.SliceStable(matched, func(i, j int) bool { return postBefore(loc, matched[j], matched[i]) })
+	case "date_asc":
+		sort.SliceStable(matched, func(i, j int) bool { return postBefore(loc, matched[i], matched[j]) })
+	case "title":
+		sort.SliceStable(matched, func(i, j int) bool {
+			return strings.ToLower(matched[i].Title()) < strings.ToLower(matched[j].Title())
+		})
+	}
+
+	// Paginate
+	output := ListPostsOutput{Posts: []PostSummary{}, Total: len(matched)}
+	if offset > len(matched) {
+		offset = len(matched)
+	}
+	end := offset + limit
+	if end > len(matched) {
+		end = len(matched)
+	}
+	for _, post := range matched[offset:end] {
+		output.Posts = append(output.Posts, summarizePost(post, loc))
+	}
+	if end < len(matched) {
+		output.NextCursor = encodeCursor(end)
+	}
+
+	structured, err := json.MarshalIndent(output, "", "  ")
+	if err != nil {
+		return &Response{
+			JSONRPC: "2.0",
+			ID:      id,
+			Error:   &Error{Code: 1, Message: fmt.Sprintf("Failed to encode result: %v", err)},
+		}
+	}
+
+	content := []Content{
+		{Type: "text", Text: formatPostList(output, offset, noun)},
+		{Type: "text", Text: string(structured)},
+	}
+
+	return &Response{
+		JSONRPC: "2.0",
+		ID:      id,
+		Result:  ToolCallResult{Content: content, StructuredContent: output},
+	}
+}
+
+func hasTag(post *Post, tag string) bool {
+	for _, t := range post.Tags() {
+		if strings.EqualFold(t, tag) {
+			return true
+		}
+	}
+	return false
+}
+
+// postBefore orders posts by date, falling back to their path when a date is missing.
+func postBefore(loc *time.Location, a, b *Post) bool {
+	da, okA := a.Date(loc)
+	db, okB := b.Date(loc)
+	if okA && okB && !da.Equal(db) {
+		return da.Before(db)
+	}
+	return a.RelPath < b.RelPath
+}
+
+func summarizePost(post *Post, loc *time.Location) PostSummary {
+	summary := PostSummary{
+		URI:   post.URI(),
+		Path:  post.Path,
+		Title: post.Title(),
+		Slug:  post.Slug(),
+		Tags:  post.Tags(),
+	}
+	if summary.Tags == nil {
+		summary.Tags = []string{}
+	}
+	if date, ok := post.Date(loc); ok {
+		summary.Date = date.Format(dateLayout)
+	}
+	summary.Lang, _ = post.FrontMatter["lang"].(string)
+	if abstract, ok := post.FrontMatter["abstract"].(string); ok {
+		summary.Abstract = strings.Join(strings.Fields(abstract), " ")
+	}
+	return summary
+}
+
+func formatPostList(output ListPostsOutput, offset int, noun string) string {
+	if output.Total == 0 {
+		return fmt.Sprintf("No %s found.", noun)
+	}
+
+	var b strings.Builder
+	fmt.Fprintf(&b, "Showing %d-%d of %d %s:\n\n", offset+1, offset+len(output.Posts), output.Total, noun)
+	for _, post := range output.Posts {
+		date := post.Date
+		if len(date) >= 10 {
+			date = date[:10]
+		}
+		fmt.Fprintf(&b, "%s  %s [%s]", date, post.Title, strings.Join(post.Tags, ", "))
+		if post.Lang != "" {
+			fmt.Fprintf(&b, " (%s)", post.Lang)
+		}
+		fmt.Fprintf(&b, "\n  %s\n", post.URI)
+	}
+	if output.NextCursor != "" {
+		fmt.Fprintf(&b, "\nMore results available, call again with cursor: %s\n", output.NextCursor)
+	}
+	return b.String()
+}
+
+func encodeCursor(offset int) string {
+	return base64.RawURLEncoding.EncodeToString([]byte(strconv.Itoa(offset)))
+}
+
+func decodeCursor(cursor string) (int, error) {
+	data, err := base64.RawURLEncoding.DecodeString(cursor)
+	if err != nil {
+		return 0, err
+	}
+	offset, err := strconv.Atoi(string(data))
+	if err != nil || offset < 0 {
+		return 0, fmt.Errorf("invalid cursor")
+	}
+	return offset, nil
+}
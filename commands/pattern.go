@@ -0,0 +1,381 @@
+package commands
+
+import (
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Path patterns are small templates. {name} is replaced by a value of the
+// post, and {cond:body} is an optional segment that is only written when
+// cond holds, e.g. {lang!=en:/{lang}}. cond is a name, true when the value
+// is set, or name=value / name!=value.
+
+// patternPlaceholders describes the placeholders, in the order they are
+// documented. {meta.FIELD} is handled separately.
+var patternPlaceholders = []struct {
+	name, expr, desc string
+}{
+	{"yyyy", `\d{4}`, "year"},
+	{"yy", `\d{2}`, "two-digit year"},
+	{"MM", `\d{2}`, "month"},
+	{"DD", `\d{2}`, "day"},
+	{"HH", `\d{2}`, "hour"},
+	{"mm", `\d{2}`, "minute"},
+	{"week", `\d{2}`, "ISO 8601 week"},
+	{"slug", `[^/]+`, "slug"},
+	{"lang", `[^/]+`, "language"},
+	{"first_tag", `[^/]+`, "first tag, slugified"},
+	{"section", `[^/]+`, "section front matter field, slugified"},
+	{"id", `[^/]+`, "id front matter field, or a sortable short id from the date"},
+}
+
+// pathTemplate is a parsed path pattern.
+type pathTemplate []templateNode
+
+type templateNode struct {
+	text     string       // Literal text, when name is empty
+	name     string       // Placeholder, e.g. "yyyy" or "meta.series"
+	optional bool         // An optional segment: body is written when the condition holds
+	op       string       // "", "=" or "!="
+	value    string       // What op compares the placeholder with
+	body     pathTemplate // The optional segment
+}
+
+// parsePattern parses a path pattern, rejecting unknown placeholders and
+// patterns that could write outside root_path or give posts the same path.
+func parsePattern(pattern string) (pathTemplate, error) {
+	if strings.TrimSpace(pattern) == "" {
+		return nil, fmt.Errorf("pattern is empty")
+	}
+	if strings.HasPrefix(pattern, "/") || strings.HasPrefix(pattern, "~") {
+		return nil, fmt.Errorf("pattern must be relative to root_path: %s", pattern)
+	}
+	for _, segment := range strings.Split(pattern, "/") {
+		if segment == ".." {
+			return nil, fmt.Errorf("pattern must not contain ..: %s", pattern)
+		}
+	}
+
+	t, rest, err := parseNodes(pattern, false)
+	if err != nil {
+		return nil, err
+	}
+	if rest != "" {
+		return nil, fmt.Errorf("unexpected } in %s", pattern)
+	}
+	if !t.uses("slug") && !t.uses("id") {
+		return nil, fmt.Errorf("pattern needs {slug} or {id}, or every post gets the same path: %s", pattern)
+	}
+	return t, nil
+}
+
+// parseNodes parses s up to the end, or up to the } closing an optional
+// segment when nested, and returns what follows it.
+func parseNodes(s string, nested bool) (pathTemplate, string, error) {
+	var t pathTemplate
+	for s != "" {
+		switch s[0] {
+		case '}':
+			if nested {
+				return t, s[1:], nil
+			}
+			return t, s, nil
+
+		case '{':
+			end := strings.IndexAny(s[1:], "{}:")
+			if end < 0 || s[1+end] == '{' {
+				return nil, "", fmt.Errorf("unterminated placeholder: %s", s)
+			}
+			head := s[1 : 1+end]
+			if s[1+end] == '}' {
+				if err := checkPlaceholder(head); err != nil {
+					return nil, "", err
+				}
+				t = append(t, templateNode{name: head})
+				s = s[end+2:]
+				continue
+			}
+
+			node := templateNode{optional: true, name: head}
+			if i := strings.Index(head, "!="); i >= 0 {
+				node.name, node.op, node.value = head[:i], "!=", head[i+2:]
+			} else if i := strings.Index(head, "="); i >= 0 {
+				node.name, node.op, node.value = head[:i], "=", head[i+1:]
+			}
+			if err := checkPlaceholder(node.name); err != nil {
+				return nil, "", err
+			}
+			body, rest, err := parseNodes(s[end+2:], true)
+			if err != nil {
+				return nil, "", err
+			}
+			node.body = body
+			t = append(t, node)
+			s = rest
+
+		default:
+			end := strings.IndexAny(s, "{}")
+			if end < 0 {
+				end = len(s)
+			}
+			t = append(t, templateNode{text: s[:end]})
+			s = s[end:]
+		}
+	}
+	if nested {
+		return nil, "", fmt.Errorf("unterminated optional segment, a } is missing")
+	}
+	return t, "", nil
+}
+
+func checkPlaceholder(name string) error {
+	if field, ok := strings.CutPrefix(name, "meta."); ok {
+		if field == "" || strings.ContainsAny(field, "/ ") {
+			return fmt.Errorf("invalid placeholder {%s}, use {meta.FIELD} with a front matter field name", name)
+		}
+		return nil
+	}
+	for _, p := range patternPlaceholders {
+		if p.name == name {
+			return nil
+		}
+	}
+	return fmt.Errorf("unknown placeholder {%s}", name)
+}
+
+// uses reports whether the template has a placeholder outside optional
+// segments.
+func (t pathTemplate) uses(name string) bool {
+	for _, node := range t {
+		if node.name == name && !node.optional {
+			return true
+		}
+	}
+	return false
+}
+
+// regexp returns an expression matching the paths the template produces.
+func (t pathTemplate) regexp() string {
+	var expr strings.Builder
+	for _, node := range t {
+		switch {
+		case node.optional:
+			expr.WriteString("(?:" + node.body.regexp() + ")?")
+		case node.name != "":
+			expr.WriteString(placeholderExpr(node.name))
+		default:
+			expr.WriteString(regexp.QuoteMeta(node.text))
+		}
+	}
+	return expr.String()
+}
+
+func placeholderExpr(name string) string {
+	for _, p := range patternPlaceholders {
+		if p.name == name {
+			return p.expr
+		}
+	}
+	return `[^/]+`
+}
+
+// render fills in the template. value returns a placeholder's value, or
+// an error explaining why the post has none.
+func (t pathTemplate) render(value func(name string) (string, error)) (string, error) {
+	var b strings.Builder
+	for _, node := range t {
+		switch {
+		case node.optional:
+			v, err := value(node.name)
+			if err != nil {
+				v = ""
+			}
+			holds := v != ""
+			switch node.op {
+			case "=":
+				holds = strings.EqualFold(v, node.value)
+			case "!=":
+				holds = !strings.EqualFold(v, node.value)
+			}
+			if !holds {
+				continue
+			}
+			body, err := node.body.render(value)
+			if err != nil {
+				return "", err
+			}
+			b.WriteString(body)
+		case node.name != "":
+			v, err := value(node.name)
+			if err != nil {
+				return "", err
+			}
+			b.WriteString(v)
+		default:
+			b.WriteString(node.text)
+		}
+	}
+	return b.String(), nil
+}
+
+// patternRegexp turns a path pattern into a regexp matching relative paths.
+func patternRegexp(pattern string) (*regexp.Regexp, error) {
+	t, err := parsePattern(pattern)
+	if err != nil {
+		return nil, err
+	}
+	return regexp.Compile("^" + t.regexp() + "$")
+}
+
+// renderPattern computes a post's path from pattern and its front matter.
+func renderPattern(cfg Config, pattern string, frontMatter map[string]interface{}) (string, error) {
+	t, err := parsePattern(pattern)
+	if err != nil {
+		return "", err
+	}
+
+	lang, _ := frontMatter["lang"].(string)
+	date, hasDate := parseDateValue(frontMatter["date"], cfg.location())
+
+	path, err := t.render(func(name string) (string, error) {
+		switch name {
+		case "yyyy", "yy", "MM", "DD", "HH", "mm", "week", "id":
+			if name == "id" {
+				if id := frontMatterString(frontMatter["id"]); id != "" {
+					return slugify(id, lang, cfg)
+				}
+			}
+			if !hasDate {
+				return "", fmt.Errorf("the post has no valid date (got %v)", frontMatter["date"])
+			}
+			return dateValue(name, date), nil
+		case "slug":
+			if slug := frontMatterString(frontMatter["slug"]); slug != "" {
+				if strings.ContainsAny(slug, `/\`) || slug == "." || slug == ".." {
+					return "", fmt.Errorf("the slug %q is not a valid file name", slug)
+				}
+				return slug, nil
+			}
+			return "", fmt.Errorf("the post has no slug")
+		case "lang":
+			if lang != "" {
+				return slugify(lang, "", cfg)
+			}
+			return "", fmt.Errorf("the post has no lang")
+		case "first_tag":
+			if tags := frontMatterTags(frontMatter["tags"]); len(tags) > 0 {
+				return slugify(tags[0], lang, cfg)
+			}
+			return "", fmt.Errorf("the post has no tags")
+		}
+
+		field := strings.TrimPrefix(name, "meta.")
+		if v := frontMatterString(frontMatter[field]); v != "" {
+			return slugify(v, lang, cfg)
+		}
+		return "", fmt.Errorf("the post has no %s", field)
+	})
+	if err != nil {
+		return "", fmt.Errorf("cannot compute the path from %s: %v", pattern, err)
+	}
+	return path, nil
+}
+
+// dateValue formats one of the date placeholders.
+func dateValue(name string, date time.Time) string {
+	switch name {
+	case "yy":
+		return date.Format("06")
+	case "MM":
+		return date.Format("01")
+	case "DD":
+		return date.Format("02")
+	case "HH":
+		return date.Format("15")
+	case "mm":
+		return date.Format("04")
+	case "week":
+		_, week := date.ISOWeek()
+		return fmt.Sprintf("%02d", week)
+	case "id":
+		// Seconds in base 36, padded so ids sort like dates until 2059
+		id := strconv.FormatInt(date.Unix(), 36)
+		return strings.Repeat("0", max(0, 7-len(id))) + id
+	}
+	return date.Format("2006")
+}
+
+// frontMatterString returns a scalar front matter value as text.
+func frontMatterString(value interface{}) string {
+	switch v := value.(type) {
+	case nil:
+		return ""
+	case string:
+		return strings.TrimSpace(v)
+	case bool, int, int64, float64:
+		return fmt.Sprint(v)
+	}
+	return ""
+}
+
+func frontMatterTags(value interface{}) []string {
+	var tags []string
+	switch v := value.(type) {
+	case []string:
+		tags = v
+	case []interface{}:
+		for _, t := range v {
+			if s, ok := t.(string); ok {
+				tags = append(tags, s)
+			}
+		}
+	}
+	var out []string
+	for _, tag := range tags {
+		if strings.TrimSpace(tag) != "" {
+			out = append(out, tag)
+		}
+	}
+	return out
+}
+
+// perPostDir reports whether a pattern gives every post its own directory,
+// so the directory can move along with the post.
+func perPostDir(pattern string) bool {
+	i := strings.LastIndex(pattern, "/")
+	if i < 0 {
+		return false
+	}
+	return strings.Contains(pattern[:i], "{slug}") || strings.Contains(pattern[:i], "{id}")
+}
+
+// checkPatterns validates the path patterns of a config, with errors
+// pointing at the settings.
+func (c Config) checkPatterns() *ValidationError {
+	verr := &ValidationError{}
+	for _, p := range []struct{ key, pattern string }{
+		{"path_pattern", c.PathPattern},
+		{"drafts_pattern", c.draftsPattern()},
+		{"scheduled_pattern", c.scheduledPattern()},
+	} {
+		if _, err := parsePattern(p.pattern); err != nil {
+			verr.add("/"+p.key, "%v", err).Hint = "Use the placeholders " + patternHelp
+		}
+	}
+	if len(verr.Errors) > 0 {
+		return verr
+	}
+	return nil
+}
+
+// patternHelp lists the placeholders for hints and previews.
+var patternHelp = func() string {
+	var names []string
+	for _, p := range patternPlaceholders {
+		names = append(names, "{"+p.name+"}")
+	}
+	return strings.Join(names, " ") + " {meta.FIELD}, optional segments like {lang!=en:/{lang}}"
+}()
@@ -0,0 +1,121 @@
+package commands
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestParsePattern(t *testing.T) {
+	tests := []struct {
+		pattern string
+		wantErr string // Empty when the pattern is valid
+	}{
+		{"posts/{yyyy}/{yyyy}-{MM}-{DD}-{slug}/{slug}.md", ""},
+		{"{id}.md", ""},
+		{"posts/{lang!=en:{lang}/}{slug}.md", ""},
+		{"{section:{section}/}{first_tag}/{slug}.md", ""},
+		{"{meta.series}/{slug}.md", ""},
+		{"", "pattern is empty"},
+		{"  ", "pattern is empty"},
+		{"/posts/{slug}.md", "must be relative"},
+		{"~/posts/{slug}.md", "must be relative"},
+		{"posts/../{slug}.md", "must not contain .."},
+		{"posts/{yyyy}.md", "needs {slug} or {id}"},
+		{"posts/{lang:{slug}}.md", "needs {slug} or {id}"},
+		{"posts/{year}/{slug}.md", "unknown placeholder {year}"},
+		{"posts/{meta.}/{slug}.md", "invalid placeholder"},
+		{"posts/{slug.md", "unterminated placeholder"},
+		{"posts/{slug}}.md", "unexpected }"},
+		{"posts/{lang:{lang}/{slug}.md", "unterminated optional segment"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.pattern, func(t *testing.T) {
+			_, err := parsePattern(tt.pattern)
+			switch {
+			case tt.wantErr == "" && err != nil:
+				t.Errorf("parsePattern(%q) error = %v", tt.pattern, err)
+			case tt.wantErr != "" && err == nil:
+				t.Errorf("parsePattern(%q) succeeded, want an error containing %q", tt.pattern, tt.wantErr)
+			case tt.wantErr != "" && !strings.Contains(err.Error(), tt.wantErr):
+				t.Errorf("parsePattern(%q) error = %v, want it to contain %q", tt.pattern, err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestRenderPattern(t *testing.T) {
+	cfg := GetDefaultConfig()
+	cfg.Timezone = "Europe/Athens"
+
+	post := func(extra map[string]interface{}) map[string]interface{} {
+		fm := map[string]interface{}{
+			"title": "Hello",
+			"slug":  "hello",
+			"date":  "2025-10-07 09:30:00 +0300",
+			"lang":  "en",
+			"tags":  []interface{}{"Go Lang", "mcp"},
+		}
+		for k, v := range extra {
+			fm[k] = v
+		}
+		return fm
+	}
+
+	tests := []struct {
+		name        string
+		pattern     string
+		frontMatter map[string]interface{}
+		want        string
+		wantErr     string
+	}{
+		{"default", "posts/{yyyy}/{yyyy}-{MM}-{DD}-{slug}/{slug}.md", post(nil), "posts/2025/2025-10-07-hello/hello.md", ""},
+		{"time and week", "{yy}/w{week}/{HH}{mm}-{slug}.md", post(nil), "25/w41/0930-hello.md", ""},
+		{"offset kept", "{DD}-{HH}-{slug}.md", post(map[string]interface{}{"date": "2025-10-07 23:30:00 -0500"}), "07-23-hello.md", ""},
+		{"first tag slugified", "{first_tag}/{slug}.md", post(nil), "go-lang/hello.md", ""},
+		{"meta field slugified", "{meta.series}/{slug}.md", post(map[string]interface{}{"series": "Deep Dives"}), "deep-dives/hello.md", ""},
+		{"optional set", "{section:{section}/}{slug}.md", post(map[string]interface{}{"section": "Notes"}), "notes/hello.md", ""},
+		{"optional unset", "{section:{section}/}{slug}.md", post(nil), "hello.md", ""},
+		{"optional not equal, skipped", "{lang!=en:{lang}/}{slug}.md", post(nil), "hello.md", ""},
+		{"optional not equal, written", "{lang!=en:{lang}/}{slug}.md", post(map[string]interface{}{"lang": "el"}), "el/hello.md", ""},
+		{"optional equal", "{lang=EL:greek/}{slug}.md", post(map[string]interface{}{"lang": "el"}), "greek/hello.md", ""},
+		{"id from front matter", "{id}.md", post(map[string]interface{}{"id": "Post 42"}), "post-42.md", ""},
+		{"id from date", "{id}.md", post(nil), "0t3r0q0.md", ""},
+		{"id from date in configured timezone", "{id}.md", post(map[string]interface{}{"date": "2025-10-07 09:30"}), "0t3r0q0.md", ""},
+		{"missing slug", "{slug}.md", post(map[string]interface{}{"slug": ""}), "", "the post has no slug"},
+		{"slug with slash", "{slug}.md", post(map[string]interface{}{"slug": "../etc"}), "", "not a valid file name"},
+		{"slug dot dot", "{slug}/{slug}.md", post(map[string]interface{}{"slug": ".."}), "", "not a valid file name"},
+		{"missing date", "{yyyy}/{slug}.md", post(map[string]interface{}{"date": nil}), "", "no valid date"},
+		{"relative date", "{yyyy}/{slug}.md", post(map[string]interface{}{"date": "tomorrow"}), "", "no valid date"},
+		{"missing tags", "{first_tag}/{slug}.md", post(map[string]interface{}{"tags": []interface{}{}}), "", "the post has no tags"},
+		{"missing meta field", "{meta.series}/{slug}.md", post(nil), "", "the post has no series"},
+		{"invalid pattern", "{slug", post(nil), "", "unterminated placeholder"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := renderPattern(cfg, tt.pattern, tt.frontMatter)
+			if tt.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Fatalf("renderPattern(%q) = %q, %v; want an error containing %q", tt.pattern, got, err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("renderPattern(%q) error = %v", tt.pattern, err)
+			}
+			if got != tt.want {
+				t.Errorf("renderPattern(%q) = %q, want %q", tt.pattern, got, tt.want)
+			}
+
+			// Posts are found again by matching their paths against the pattern
+			re, err := patternRegexp(tt.pattern)
+			if err != nil {
+				t.Fatalf("patternRegexp(%q) error = %v", tt.pattern, err)
+			}
+			if !re.MatchString(got) {
+				t.Errorf("patternRegexp(%q) does not match %q", tt.pattern, got)
+			}
+		})
+	}
+}
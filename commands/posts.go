@@ -0,0 +1,389 @@
+package commands
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io/fs"
+	"net/url"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"gopkg.in/yaml.v3"
+)
+
+const postURIScheme = "bckt://"
+
+// Post is a markdown file found under root_path.
+type Post struct {
+	Path        string // Absolute path on disk
+	RelPath     string // Path relative to root_path, slash separated
+	FrontMatter map[string]interface{}
+	Body        string
+	Raw         string // File contents as read from disk
+}
+
+// URI is bckt:// followed by the post's path relative to root_path,
+// percent-encoded, e.g. bckt://posts/caf%C3%A9/notes%20%231.md.
+func (p *Post) URI() string {
+	u := url.URL{Scheme: "bckt", Path: p.RelPath}
+	return u.String()
+}
+
+func (p *Post) Title() string {
+	title, _ := p.FrontMatter["title"].(string)
+	return title
+}
+
+func (p *Post) Slug() string {
+	slug, _ := p.FrontMatter["slug"].(string)
+	return slug
+}
+
+// Tags returns the post's tags, ignoring non-string entries.
+func (p *Post) Tags() []string {
+	var tags []string
+	switch v := p.FrontMatter["tags"].(type) {
+	case []interface{}:
+		for _, t := range v {
+			if tag, ok := t.(string); ok {
+				tags = append(tags, tag)
+			}
+		}
+	case []string:
+		tags = append(tags, v...)
+	}
+	return tags
+}
+
+// Draft reports whether the post is flagged with draft: true.
+func (p *Post) Draft() bool {
+	return isDraft(p.FrontMatter)
+}
+
+func isDraft(frontMatter map[string]interface{}) bool {
+	draft, _ := frontMatter["draft"].(bool)
+	return draft
+}
+
+// Date returns the parsed date field of the post, taking dates without an
+// offset in loc.
+func (p *Post) Date(loc *time.Location) (time.Time, bool) {
+	return parseDateValue(p.FrontMatter["date"], loc)
+}
+
+// scanPosts returns the published posts: every file matching the path
+// pattern that isn't flagged as a draft, newest path first.
+func scanPosts(ctx context.Context, cfg Config) ([]*Post, error) {
+	files, err := scanPattern(ctx, cfg, cfg.PathPattern)
+	if err != nil {
+		return nil, err
+	}
+
+	var posts []*Post
+	for _, post := range files {
+		if !post.Draft() {
+			posts = append(posts, post)
+		}
+	}
+	return posts, nil
+}
+
+// scanDrafts returns every file matching the drafts pattern, plus posts
+// under the path pattern flagged as drafts, newest path first.
+func scanDrafts(ctx context.Context, cfg Config) ([]*Post, error) {
+	drafts, err := scanPattern(ctx, cfg, cfg.draftsPattern())
+	if err != nil {
+		return nil, err
+	}
+	files, err := scanPattern(ctx, cfg, cfg.PathPattern)
+	if err != nil {
+		return nil, err
+	}
+
+	seen := make(map[string]bool, len(drafts))
+	for _, draft := range drafts {
+		seen[draft.Path] = true
+	}
+	for _, post := range files {
+		if post.Draft() && !seen[post.Path] {
+			drafts = append(drafts, post)
+		}
+	}
+
+	sort.Slice(drafts, func(i, j int) bool {
+		return drafts[i].RelPath > drafts[j].RelPath
+	})
+	return drafts, nil
+}
+
+// scanScheduled returns the posts in the scheduled queue, newest path
+// first.
+func scanScheduled(ctx context.Context, cfg Config) ([]*Post, error) {
+	return scanPattern(ctx, cfg, cfg.scheduledPattern())
+}
+
+// scanAll returns the published posts followed by the drafts and the
+// scheduled posts.
+func scanAll(ctx context.Context, cfg Config) ([]*Post, error) {
+	var all []*Post
+	for _, scan := range []func(context.Context, Config) ([]*Post, error){scanPosts, scanDrafts, scanScheduled} {
+		posts, err := scan(ctx, cfg)
+		if err != nil {
+			return nil, err
+		}
+		all = append(all, posts...)
+	}
+	return all, nil
+}
+
+// inPattern reports whether a post's path matches pattern.
+func inPattern(pattern string, post *Post) bool {
+	re, err := patternRegexp(pattern)
+	return err == nil && re.MatchString(post.RelPath)
+}
+
+// scanPattern walks root_path and returns every file matching pattern,
+// newest path first. Files whose front matter cannot be parsed are skipped.
+// The walk stops early when ctx is cancelled.
+func scanPattern(ctx context.Context, cfg Config, pattern string) ([]*Post, error) {
+	root := expandPath(cfg.RootPath)
+	if root == "" {
+		return nil, fmt.Errorf("root_path is not configured. Please run bckt_setup first")
+	}
+
+	re, err := patternRegexp(pattern)
+	if err != nil {
+		return nil, err
+	}
+
+	// Only walk the static part of the pattern, e.g. "posts/" for the default
+	start := root
+	if prefix := patternPrefix(pattern); prefix != "" {
+		start = filepath.Join(root, filepath.FromSlash(prefix))
+	}
+	if _, err := os.Stat(start); os.IsNotExist(err) {
+		return nil, nil
+	}
+
+	var posts []*Post
+	err = filepath.WalkDir(start, func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+		if d.IsDir() {
+			return nil
+		}
+
+		rel, err := filepath.Rel(root, path)
+		if err != nil {
+			return nil
+		}
+		rel = filepath.ToSlash(rel)
+		if !re.MatchString(rel) {
+			return nil
+		}
+
+		post, err := loadPost(path)
+		if err != nil {
+			return nil
+		}
+		post.RelPath = rel
+		posts = append(posts, post)
+		return nil
+	})
+	if ctx.Err() != nil {
+		return nil, ctx.Err()
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to scan %s: %v", start, err)
+	}
+
+	sort.Slice(posts, func(i, j int) bool {
+		return posts[i].RelPath > posts[j].RelPath
+	})
+
+	return posts, nil
+}
+
+func loadPost(path string) (*Post, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	frontMatter, body, err := parsePost(data)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %v", path, err)
+	}
+
+	return &Post{
+		Path:        path,
+		FrontMatter: frontMatter,
+		Body:        body,
+		Raw:         string(data),
+	}, nil
+}
+
+// parsePost splits a markdown file into its YAML front matter and body.
+// Files without front matter return an empty map and the whole file as body.
+func parsePost(data []byte) (map[string]interface{}, string, error) {
+	frontMatter := make(map[string]interface{})
+	yamlPart, body, err := splitFrontMatter(data)
+	if err != nil {
+		return nil, "", err
+	}
+
+	if err := yaml.NewDecoder(bytes.NewBufferString(yamlPart)).Decode(&frontMatter); err != nil && yamlPart != "" {
+		return nil, "", fmt.Errorf("invalid front matter: %v", err)
+	}
+
+	return frontMatter, body, nil
+}
+
+// splitFrontMatter returns the YAML between the --- lines at the top of a
+// markdown file and the body after them. Without front matter the YAML is
+// empty and the body is the whole file.
+func splitFrontMatter(data []byte) (string, string, error) {
+	text := strings.ReplaceAll(string(data), "\r\n", "\n")
+	if !strings.HasPrefix(text, "---\n") {
+		return "", text, nil
+	}
+
+	rest := text[len("---\n"):]
+	if strings.HasPrefix(rest, "---\n") || rest == "---" {
+		// Empty front matter
+		return "", strings.TrimLeft(strings.TrimPrefix(rest, "---"), "\n"), nil
+	}
+
+	end := strings.Index(rest, "\n---\n")
+	if end < 0 {
+		if !strings.HasSuffix(rest, "\n---") {
+			return "", "", fmt.Errorf("unterminated front matter")
+		}
+		end = len(rest) - len("\n---")
+	}
+	return rest[:end], strings.TrimLeft(rest[end+len("\n---"):], "\n"), nil
+}
+
+// patternPrefix returns the directories of a path pattern that come before
+// the first placeholder.
+func patternPrefix(pattern string) string {
+	if i := strings.Index(pattern, "{"); i >= 0 {
+		pattern = pattern[:i]
+	}
+	if i := strings.LastIndex(pattern, "/"); i >= 0 {
+		return pattern[:i]
+	}
+	return ""
+}
+
+// postFromURI resolves a bckt:// URI to a post under root_path.
+func postFromURI(cfg Config, uri string) (*Post, error) {
+	if !strings.HasPrefix(uri, postURIScheme) {
+		return nil, fmt.Errorf("unsupported resource URI: %s", uri)
+	}
+
+	// Not url.Parse, which would take the first directory for a host and
+	// refuse escapes in it
+	rel, err := url.PathUnescape(strings.TrimPrefix(uri, postURIScheme))
+	if err != nil {
+		return nil, fmt.Errorf("invalid resource URI: %s", uri)
+	}
+	clean := filepath.ToSlash(filepath.Clean(rel))
+	if rel == "" || clean != rel || strings.HasPrefix(clean, "../") || filepath.IsAbs(rel) {
+		return nil, fmt.Errorf("invalid resource URI: %s", uri)
+	}
+
+	matched := false
+	for _, pattern := range []string{cfg.PathPattern, cfg.draftsPattern(), cfg.scheduledPattern()} {
+		re, err := patternRegexp(pattern)
+		if err != nil {
+			return nil, err
+		}
+		matched = matched || re.MatchString(rel)
+	}
+	if !matched {
+		return nil, fmt.Errorf("resource does not match path_pattern, drafts_pattern or scheduled_pattern: %s", uri)
+	}
+
+	root := expandPath(cfg.RootPath)
+	if root == "" {
+		return nil, fmt.Errorf("root_path is not configured. Please run bckt_setup first")
+	}
+
+	post, err := loadPost(filepath.Join(root, filepath.FromSlash(rel)))
+	if err != nil {
+		return nil, err
+	}
+	post.RelPath = rel
+	return post, nil
+}
+
+// findPost locates an existing post or draft by path (absolute or relative
+// to root_path) or by slug. Callers write the post back, so posts outside
+// what confinePath allows are refused.
+func findPost(ctx context.Context, cfg Config, path, slug string) (*Post, error) {
+	root := expandPath(cfg.RootPath)
+
+	if path != "" {
+		if !filepath.IsAbs(expandPath(path)) && root == "" {
+			return nil, fmt.Errorf("root_path is not configured. Please run bckt_setup first")
+		}
+		// Posts are found to be written back, so they have to be in the sandbox
+		fullPath, err := confinePath(cfg, path)
+		if err != nil {
+			return nil, err
+		}
+
+		post, err := loadPost(fullPath)
+		if err != nil {
+			if os.IsNotExist(err) {
+				return nil, fmt.Errorf("post not found: %s", fullPath)
+			}
+			return nil, err
+		}
+		if rel, err := filepath.Rel(root, fullPath); err == nil && root != "" {
+			post.RelPath = filepath.ToSlash(rel)
+		}
+		return post, nil
+	}
+
+	if slug == "" {
+		return nil, fmt.Errorf("either path or slug is required")
+	}
+
+	posts, err := scanAll(ctx, cfg)
+	if err != nil {
+		return nil, err
+	}
+
+	var found []*Post
+	for _, post := range posts {
+		if post.Slug() == slug {
+			found = append(found, post)
+		}
+	}
+
+	switch len(found) {
+	case 0:
+		return nil, fmt.Errorf("no post found with slug: %s", slug)
+	case 1:
+		if _, err := confinePath(cfg, found[0].Path); err != nil {
+			return nil, err
+		}
+		return found[0], nil
+	default:
+		var paths []string
+		for _, post := range found {
+			paths = append(paths, post.RelPath)
+		}
+		return nil, fmt.Errorf("slug %s matches %d posts, use path instead: %s", slug, len(found), strings.Join(paths, ", "))
+	}
+}
@@ -0,0 +1,52 @@
+package commands
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestPostURIRoundTrip(t *testing.T) {
+	store, root := newTestBlog(t)
+	if err := store.Update(func(cfg *Config) error {
+		cfg.PathPattern = "{meta.section}/{slug}.md"
+		return nil
+	}); err != nil {
+		t.Fatal(err)
+	}
+	cfg := store.Get()
+
+	tests := []struct {
+		rel  string
+		want string // Expected URI
+	}{
+		{"notes/plain.md", "bckt://notes/plain.md"},
+		{"notes/καλημέρα.md", "bckt://notes/%CE%BA%CE%B1%CE%BB%CE%B7%CE%BC%CE%AD%CF%81%CE%B1.md"},
+		{"notes/issue #1?.md", "bckt://notes/issue%20%231%3F.md"},
+		{"notes/100%.md", "bckt://notes/100%25.md"},
+		{"my notes/post.md", "bckt://my%20notes/post.md"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.rel, func(t *testing.T) {
+			writeTestPost(t, root, tt.rel, "---\ntitle: A Post\n---\n\nBody.\n")
+
+			uri := (&Post{RelPath: tt.rel}).URI()
+			if uri != tt.want {
+				t.Errorf("URI() = %s, want %s", uri, tt.want)
+			}
+			post, err := postFromURI(cfg, uri)
+			if err != nil {
+				t.Fatalf("postFromURI(%s) error = %v", uri, err)
+			}
+			if post.RelPath != tt.rel || !strings.Contains(post.Raw, "title: A Post") {
+				t.Errorf("postFromURI(%s) found %s", uri, post.RelPath)
+			}
+		})
+	}
+
+	for _, uri := range []string{"bckt://notes/%2E%2E/%2E%2E/secret.md", "bckt://notes/%zz.md", "file:///etc/passwd"} {
+		if _, err := postFromURI(cfg, uri); err == nil {
+			t.Errorf("postFromURI(%s) succeeded, want an error", uri)
+		}
+	}
+}
@@ -0,0 +1,169 @@
+package commands
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"time"
+)
+
+func init() {
+	registerTool(&Tool{
+		Name:        "bckt_publish",
+		Title:       "Publish Draft",
+		Description: "Publish a draft. Finds the draft by path or slug, removes the draft flag, stamps the publish date (now, unless date is given), recomputes the path from path_pattern and moves the draft there, together with its directory when both patterns give every post its own directory.",
+		Annotations: writeTool,
+		Output:      PublishOutput{},
+		Handler:     handler(HandleBcktPublish),
+		Complete: map[string]Completer{
+			"/path": CompleteDraftPaths,
+			"/slug": CompleteDraftSlugs,
+		},
+	})
+}
+
+func HandleBcktPublish(ctx context.Context, id interface{}, args PublishArgs, store *ConfigStore) *Response {
+	if args.Path == "" && args.Slug == "" {
+		return invalidArgument(id, "/path", "path or slug is required", "Pass the draft's path, or its slug as slug. bckt_list_drafts lists them")
+	}
+
+	cfg := store.Get()
+
+	date := time.Now().In(cfg.location())
+	if args.Date != "" {
+		var err error
+		if date, err = parseDate(args.Date, cfg.location(), time.Now()); err != nil {
+			return invalidArgument(id, "/date", err.Error(), dateHint+", or leave date out to publish now")
+		}
+		date = date.In(cfg.location())
+	}
+
+	post, err := findPost(ctx, cfg, args.Path, args.Slug)
+	if err != nil {
+		return &Response{
+			JSONRPC: "2.0",
+			ID:      id,
+			Error:   &Error{Code: 1, Message: err.Error()},
+		}
+	}
+
+	draftsDir := inPattern(cfg.draftsPattern(), post)
+	if !post.Draft() && !draftsDir {
+		pointer := "/path"
+		if args.Path == "" {
+			pointer = "/slug"
+		}
+		return invalidArgument(id, pointer, fmt.Sprintf("%s is not a draft", post.Path), "Pass a draft, bckt_list_drafts lists them")
+	}
+
+	frontMatter := make(map[string]interface{}, len(post.FrontMatter))
+	for k, v := range post.FrontMatter {
+		frontMatter[k] = v
+	}
+	delete(frontMatter, "draft")
+	frontMatter["date"] = date.Format(dateLayout)
+
+	// A published post has to pass the checks a new post would
+	if _, err := validateFrontMatter(frontMatter, cfg, false); err != nil {
+		if verr, ok := err.(*ValidationError); ok {
+			return problemsFound(id, "The draft is not ready to publish, nothing was done. Fix these with bckt_update and publish again:", verr)
+		}
+	}
+
+	markdown, err := rewritePost(post.Raw, frontMatter, post.Body)
+	if err != nil {
+		return &Response{
+			JSONRPC: "2.0",
+			ID:      id,
+			Error:   &Error{Code: 1, Message: err.Error()},
+		}
+	}
+
+	oldPattern := cfg.PathPattern
+	if draftsDir {
+		oldPattern = cfg.draftsPattern()
+	}
+	oldDir, newDir, newPath, err := planMove(cfg, post.Path, oldPattern, frontMatter)
+	if err != nil {
+		return &Response{
+			JSONRPC: "2.0",
+			ID:      id,
+			Error:   &Error{Code: 1, Message: err.Error()},
+		}
+	}
+
+	output := PublishOutput{
+		Preview:   args.Preview,
+		Scheduled: isScheduled(cfg, frontMatter, time.Now()),
+		From:      oldDir,
+		To:        newDir,
+		Path:      newPath,
+		Date:      frontMatter["date"].(string),
+		Markdown:  markdown,
+	}
+	summary := fmt.Sprintf("Publish %s\n  from: %s\n  to:   %s\n  date: %s", post.Title(), oldDir, newDir, output.Date)
+	if output.Scheduled {
+		summary += "\nThe date is in the future, so the post waits in the scheduled queue until bckt_publish_due moves it into place."
+	}
+
+	if args.Preview {
+		content := []Content{
+			{Type: "text", Text: "PREVIEW MODE - Not saved"},
+			{Type: "text", Text: summary},
+			{Type: "text", Text: markdown},
+		}
+		return &Response{
+			JSONRPC: "2.0",
+			ID:      id,
+			Result:  ToolCallResult{Content: content, StructuredContent: output},
+		}
+	}
+
+	if stop := stopIfDone(ctx, id); stop != nil {
+		return stop
+	}
+	if err := applyMove(cfg, post.Path, oldDir, newDir, newPath, markdown); err != nil {
+		return &Response{
+			JSONRPC: "2.0",
+			ID:      id,
+			Error:   &Error{Code: 1, Message: err.Error()},
+		}
+	}
+
+	content := []Content{
+		{Type: "text", Text: "✓ " + summary},
+	}
+
+	return &Response{
+		JSONRPC: "2.0",
+		ID:      id,
+		Result:  ToolCallResult{Content: content, StructuredContent: output},
+	}
+}
+
+// planMove works out where a post living under oldPattern goes for its
+// front matter. The post's directory moves along when both patterns give
+// every post its own directory, so co-located assets follow it.
+func planMove(cfg Config, oldPath, oldPattern string, frontMatter map[string]interface{}) (oldDir, newDir, newPath string, err error) {
+	newPath, err = computePostPath(cfg, frontMatter)
+	if err != nil {
+		return "", "", "", err
+	}
+	if perPostDir(oldPattern) && perPostDir(postPattern(cfg, frontMatter)) {
+		return filepath.Dir(oldPath), filepath.Dir(newPath), newPath, nil
+	}
+	return oldPath, newPath, newPath, nil
+}
+
+// applyMove writes markdown and moves the post as planned by planMove,
+// refusing to replace anything at the target.
+func applyMove(cfg Config, oldPath, oldDir, newDir, newPath, markdown string) error {
+	if newPath == oldPath {
+		return writePost(cfg, oldPath, []byte(markdown))
+	}
+	if _, err := os.Stat(newDir); err == nil {
+		return fmt.Errorf("Target already exists: %s", newDir)
+	}
+	return movePost(cfg, oldPath, oldDir, newDir, newPath, markdown)
+}
@@ -0,0 +1,93 @@
+package commands
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestPublish(t *testing.T) {
+	draftRel := "drafts/post/post.md"
+	draft := strings.Join([]string{
+		"---",
+		"title: 'A Post' # shown in lists",
+		"slug: post",
+		"draft: true",
+		"tags: [go, mcp]",
+		"abstract: \"\"",
+		"lang: en",
+		"---",
+		"",
+		"Body.",
+		"",
+	}, "\n")
+	published := strings.Join([]string{
+		"---",
+		"title: 'A Post' # shown in lists",
+		"slug: post",
+		"tags: [go, mcp]",
+		"abstract: \"\"",
+		"lang: en",
+		"date: 2025-10-07 09:30:00 +0000",
+		"---",
+		"",
+		"Body.",
+		"",
+	}, "\n")
+
+	for _, preview := range []bool{false, true} {
+		name := "publish"
+		if preview {
+			name = "preview"
+		}
+		t.Run(name, func(t *testing.T) {
+			store, root := newTestBlog(t)
+			draftPath := writeTestPost(t, root, draftRel, draft)
+			writeTestPost(t, root, "drafts/post/image.png", "png")
+
+			args := map[string]interface{}{"slug": "post", "date": "2025-10-07 09:30", "preview": preview}
+			output := toolResult(t, callTool(t, store, "bckt_publish", args)).StructuredContent.(PublishOutput)
+
+			newPath := filepath.Join(root, "posts/2025/2025-10-07-post/post.md")
+			if output.Path != newPath {
+				t.Errorf("path = %s, want %s", output.Path, newPath)
+			}
+			if output.Scheduled {
+				t.Errorf("a post dated in the past was scheduled")
+			}
+			if output.Markdown != published {
+				t.Errorf("markdown =\n%s\nwant\n%s", output.Markdown, published)
+			}
+
+			if preview {
+				if got := readTestFile(t, draftPath); got != draft {
+					t.Errorf("preview changed the draft:\n%s", got)
+				}
+				if _, err := os.Stat(newPath); !os.IsNotExist(err) {
+					t.Errorf("preview wrote the post (%v)", err)
+				}
+				return
+			}
+			if _, err := os.Stat(filepath.Dir(draftPath)); !os.IsNotExist(err) {
+				t.Errorf("draft directory is still there (%v)", err)
+			}
+			if got := readTestFile(t, newPath); got != published {
+				t.Errorf("published post =\n%s\nwant\n%s", got, published)
+			}
+			if got := readTestFile(t, filepath.Join(filepath.Dir(newPath), "image.png")); got != "png" {
+				t.Errorf("asset did not move with the draft")
+			}
+		})
+	}
+}
+
+func TestPublishNotADraft(t *testing.T) {
+	store, root := newTestBlog(t)
+	writeTestPost(t, root, "posts/2025/2025-10-07-post/post.md", testPost("post", "Body."))
+
+	text := toolError(t, callTool(t, store, "bckt_publish", map[string]interface{}{"path": "posts/2025/2025-10-07-post/post.md"}))
+	if !strings.Contains(text, "is not a draft") {
+		t.Errorf("error does not say the post is not a draft:\n%s", text)
+	}
+}
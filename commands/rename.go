@@ -0,0 +1,228 @@
+package commands
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+func init() {
+	registerTool(&Tool{
+		Name:        "bckt_rename",
+		Title:       "Rename Post",
+		Description: "Change the slug of an existing post. Recomputes the path for the new slug, moves the post (and its directory with co-located assets when the path pattern gives each post its own directory), updates the slug and records the old slug in the aliases front matter list so old URLs keep working.",
+		Annotations: writeTool,
+		Output:      RenameOutput{},
+		Handler:     handler(HandleBcktRename),
+		Complete: map[string]Completer{
+			"/path": CompletePaths,
+			"/slug": CompleteSlugs,
+		},
+	})
+}
+
+func HandleBcktRename(ctx context.Context, id interface{}, args RenameArgs, store *ConfigStore) *Response {
+	if args.Path == "" && args.Slug == "" {
+		return invalidArgument(id, "/path", "path or slug is required", "Pass the post's path, or its current slug as slug")
+	}
+	if args.NewSlug == "" {
+		return invalidArgument(id, "/new_slug", "is required", "The new slug, e.g. \"my-new-slug\"")
+	}
+
+	cfg := store.Get()
+
+	if !validSlug(args.NewSlug) {
+		example, _ := slugify(args.NewSlug, "", cfg)
+		return invalidArgument(id, "/new_slug", fmt.Sprintf("%q is not a valid slug", args.NewSlug), fmt.Sprintf("Use lowercase letters, digits and dashes, e.g. %q", example))
+	}
+
+	post, err := findPost(ctx, cfg, args.Path, args.Slug)
+	if err != nil {
+		return &Response{
+			JSONRPC: "2.0",
+			ID:      id,
+			Error:   &Error{Code: 1, Message: err.Error()},
+		}
+	}
+
+	oldSlug := post.Slug()
+	if oldSlug == args.NewSlug {
+		return invalidArgument(id, "/new_slug", fmt.Sprintf("post already has slug %s", oldSlug), "Pass a different new_slug")
+	}
+
+	frontMatter := make(map[string]interface{}, len(post.FrontMatter))
+	for k, v := range post.FrontMatter {
+		frontMatter[k] = v
+	}
+	frontMatter["slug"] = args.NewSlug
+	aliases := addAlias(frontMatter["aliases"], oldSlug, args.NewSlug)
+	frontMatter["aliases"] = aliases
+
+	markdown, err := rewritePost(post.Raw, frontMatter, post.Body)
+	if err != nil {
+		return &Response{
+			JSONRPC: "2.0",
+			ID:      id,
+			Error:   &Error{Code: 1, Message: err.Error()},
+		}
+	}
+
+	oldPath := post.Path
+	newPath, err := computePostPath(cfg, frontMatter)
+	if err != nil {
+		return &Response{
+			JSONRPC: "2.0",
+			ID:      id,
+			Error:   &Error{Code: 1, Message: err.Error()},
+		}
+	}
+
+	// When the pattern gives every post its own directory, move the
+	// directory so co-located assets follow the post
+	oldDir, newDir := oldPath, newPath
+	if perPostDir(postPattern(cfg, frontMatter)) {
+		oldDir, newDir = filepath.Dir(oldPath), filepath.Dir(newPath)
+	}
+
+	summary := fmt.Sprintf("Rename %s → %s\n  from: %s\n  to:   %s\n  aliases: %v",
+		oldSlug, args.NewSlug, oldDir, newDir, aliases)
+
+	output := RenameOutput{
+		Preview: args.Preview,
+		OldSlug: oldSlug,
+		NewSlug: args.NewSlug,
+		From:    oldDir,
+		To:      newDir,
+		Path:    newPath,
+		Aliases: aliases,
+	}
+
+	if args.Preview {
+		content := []Content{
+			{Type: "text", Text: "PREVIEW MODE - Not saved"},
+			{Type: "text", Text: summary},
+			{Type: "text", Text: markdown},
+		}
+		return &Response{
+			JSONRPC: "2.0",
+			ID:      id,
+			Result:  ToolCallResult{Content: content, StructuredContent: output},
+		}
+	}
+
+	if _, err := os.Stat(newDir); err == nil {
+		return &Response{
+			JSONRPC: "2.0",
+			ID:      id,
+			Error:   &Error{Code: 1, Message: fmt.Sprintf("Target already exists: %s", newDir)},
+		}
+	}
+
+	if stop := stopIfDone(ctx, id); stop != nil {
+		return stop
+	}
+	if err := movePost(cfg, oldPath, oldDir, newDir, newPath, markdown); err != nil {
+		return &Response{
+			JSONRPC: "2.0",
+			ID:      id,
+			Error:   &Error{Code: 1, Message: err.Error()},
+		}
+	}
+
+	content := []Content{
+		{Type: "text", Text: "✓ " + summary},
+	}
+
+	return &Response{
+		JSONRPC: "2.0",
+		ID:      id,
+		Result:  ToolCallResult{Content: content, StructuredContent: output},
+	}
+}
+
+// movePost writes the updated markdown, then moves oldDir (a post directory
+// or the post file itself) to newDir and makes sure the post ends up at newPath.
+func movePost(cfg Config, oldPath, oldDir, newDir, newPath, markdown string) error {
+	for _, p := range []string{oldDir, newDir, newPath} {
+		if _, err := confinePath(cfg, p); err != nil {
+			return err
+		}
+	}
+
+	original, err := os.ReadFile(oldPath)
+	if err != nil {
+		return fmt.Errorf("Failed to read post: %v", err)
+	}
+	defer forgetPostTags()
+	if string(original) != markdown {
+		if err := writePost(cfg, oldPath, []byte(markdown)); err != nil {
+			return fmt.Errorf("Failed to write file: %v", err)
+		}
+	}
+
+	restore := func(err error) error {
+		writeFileAtomic(oldPath, original, 0644)
+		return err
+	}
+
+	if err := os.MkdirAll(filepath.Dir(newDir), 0755); err != nil {
+		return restore(fmt.Errorf("Failed to create directories: %v", err))
+	}
+	if err := os.Rename(oldDir, newDir); err != nil {
+		return restore(fmt.Errorf("Failed to move %s: %v", oldDir, err))
+	}
+
+	// The file inside a moved directory may still carry the old slug
+	moved := filepath.Join(newDir, strings.TrimPrefix(oldPath, oldDir))
+	if moved != newPath {
+		// Put the directory back too when the file can't follow
+		moveBack := func(err error) error {
+			if backErr := os.Rename(newDir, oldDir); backErr != nil {
+				return fmt.Errorf("%v; moving %s back to %s also failed: %v", err, newDir, oldDir, backErr)
+			}
+			return restore(err)
+		}
+		if err := os.MkdirAll(filepath.Dir(newPath), 0755); err != nil {
+			return moveBack(fmt.Errorf("Failed to create directories: %v", err))
+		}
+		if err := os.Rename(moved, newPath); err != nil {
+			return moveBack(fmt.Errorf("Failed to move %s: %v", moved, err))
+		}
+	}
+
+	return nil
+}
+
+// addAlias appends slug to an aliases front matter value, dropping
+// duplicates and the post's current slug.
+func addAlias(aliases interface{}, slug, current string) []string {
+	result := []string{}
+	seen := map[string]bool{current: true}
+
+	add := func(alias string) {
+		if alias != "" && !seen[alias] {
+			seen[alias] = true
+			result = append(result, alias)
+		}
+	}
+
+	switch v := aliases.(type) {
+	case []interface{}:
+		for _, a := range v {
+			if alias, ok := a.(string); ok {
+				add(alias)
+			}
+		}
+	case []string:
+		for _, alias := range v {
+			add(alias)
+		}
+	case string:
+		add(v)
+	}
+	add(slug)
+
+	return result
+}
@@ -0,0 +1,95 @@
+package commands
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestRename(t *testing.T) {
+	oldRel := "posts/2025/2025-10-07-post/post.md"
+	newRel := "posts/2025/2025-10-07-new-post/new-post.md"
+
+	tests := []struct {
+		name        string
+		markdown    string
+		preview     bool
+		wantAliases []string
+	}{
+		{
+			name:        "old slug becomes an alias",
+			markdown:    testPost("post", "Body."),
+			wantAliases: []string{"post"},
+		},
+		{
+			name:        "existing aliases kept",
+			markdown:    strings.Replace(testPost("post", "Body."), "lang: en\n", "lang: en\naliases: [first, new-post]\n", 1),
+			wantAliases: []string{"first", "post"},
+		},
+		{
+			name:        "preview",
+			markdown:    testPost("post", "Body."),
+			preview:     true,
+			wantAliases: []string{"post"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			store, root := newTestBlog(t)
+			oldPath := writeTestPost(t, root, oldRel, tt.markdown)
+			writeTestPost(t, root, "posts/2025/2025-10-07-post/image.png", "png")
+
+			args := map[string]interface{}{"path": oldRel, "new_slug": "new-post", "preview": tt.preview}
+			output := toolResult(t, callTool(t, store, "bckt_rename", args)).StructuredContent.(RenameOutput)
+
+			if !reflect.DeepEqual(output.Aliases, tt.wantAliases) {
+				t.Errorf("aliases = %v, want %v", output.Aliases, tt.wantAliases)
+			}
+			newPath := filepath.Join(root, newRel)
+			if output.Path != newPath {
+				t.Errorf("path = %s, want %s", output.Path, newPath)
+			}
+
+			if tt.preview {
+				if got := readTestFile(t, oldPath); got != tt.markdown {
+					t.Errorf("preview changed the post:\n%s", got)
+				}
+				return
+			}
+			if _, err := os.Stat(oldPath); !os.IsNotExist(err) {
+				t.Errorf("old post is still there (%v)", err)
+			}
+			saved := readTestFile(t, newPath)
+			if !strings.Contains(saved, "slug: \"new-post\"\n") {
+				t.Errorf("renamed post lacks the new slug:\n%s", saved)
+			}
+			if got := readTestFile(t, filepath.Join(filepath.Dir(newPath), "image.png")); got != "png" {
+				t.Errorf("asset did not move with the post")
+			}
+		})
+	}
+}
+
+func TestRenameWithoutSlugHasEmptyAliases(t *testing.T) {
+	store, root := newTestBlog(t)
+	if err := store.Update(func(cfg *Config) error {
+		cfg.PathPattern = "notes/{slug}.md"
+		return nil
+	}); err != nil {
+		t.Fatal(err)
+	}
+	writeTestPost(t, root, "notes/untitled.md", "---\ntitle: Untitled\n---\n\nBody.\n")
+
+	result := toolResult(t, callTool(t, store, "bckt_rename", map[string]interface{}{"path": "notes/untitled.md", "new_slug": "named"}))
+	data, err := json.Marshal(result.StructuredContent)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(string(data), `"aliases":[]`) {
+		t.Errorf("aliases are not an empty list: %s", data)
+	}
+}
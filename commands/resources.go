@@ -0,0 +1,86 @@
+package commands
+
+import (
+	"context"
+	"encoding/json"
+	"path"
+	"strings"
+)
+
+func HandleResourcesList(ctx context.Context, id interface{}, store *ConfigStore) *Response {
+	cfg := store.Get()
+
+	// Without a root_path there is nothing to list yet
+	resources := []Resource{}
+	if cfg.RootPath == "" {
+		return &Response{
+			JSONRPC: "2.0",
+			ID:      id,
+			Result:  ResourcesListResult{Resources: resources},
+		}
+	}
+
+	posts, err := scanPosts(ctx, cfg)
+	if err != nil {
+		return &Response{
+			JSONRPC: "2.0",
+			ID:      id,
+			Error:   &Error{Code: 1, Message: err.Error()},
+		}
+	}
+
+	for _, post := range posts {
+		name := post.Slug()
+		if name == "" {
+			name = strings.TrimSuffix(path.Base(post.RelPath), path.Ext(post.RelPath))
+		}
+		description, _ := post.FrontMatter["abstract"].(string)
+
+		resources = append(resources, Resource{
+			URI:         post.URI(),
+			Name:        name,
+			Title:       post.Title(),
+			Description: strings.Join(strings.Fields(description), " "),
+			MimeType:    "text/markdown",
+			Meta:        map[string]interface{}{"frontMatter": post.FrontMatter},
+		})
+	}
+
+	return &Response{
+		JSONRPC: "2.0",
+		ID:      id,
+		Result:  ResourcesListResult{Resources: resources},
+	}
+}
+
+func HandleResourcesRead(ctx context.Context, id interface{}, params json.RawMessage, store *ConfigStore) *Response {
+	var args ResourceReadParams
+	if err := json.Unmarshal(params, &args); err != nil || args.URI == "" {
+		return &Response{
+			JSONRPC: "2.0",
+			ID:      id,
+			Error:   &Error{Code: -32602, Message: "Invalid params: uri is required"},
+		}
+	}
+
+	cfg := store.Get()
+
+	post, err := postFromURI(cfg, args.URI)
+	if err != nil {
+		return &Response{
+			JSONRPC: "2.0",
+			ID:      id,
+			Error:   &Error{Code: -32002, Message: "Resource not found: " + err.Error()},
+		}
+	}
+
+	return &Response{
+		JSONRPC: "2.0",
+		ID:      id,
+		Result: ResourceReadResult{
+			Contents: []ResourceContents{
+				{URI: post.URI(), MimeType: "text/markdown", Text: post.Raw},
+			},
+		},
+	}
+}
@@ -1,83 +1,156 @@
 package commands
 
 import (
-	"encoding/json"
+	"context"
 	"fmt"
 	"os"
 	"path/filepath"
+	"strings"
+
+	"github.com/BurntSushi/toml"
 )
 
-func HandleBcktSave(id interface{}, params ToolCallParams, globalConfig *Config) *Response {
-	var args struct {
-		Markdown string `json:"markdown"`
-		Path     string `json:"path"`
-		RootPath string `json:"root_path,omitempty"`
+func init() {
+	registerTool(&Tool{
+		Name:        "bckt_save",
+		Title:       "Save Blog Post",
+		Description: "Save the formatted markdown. The path is computed from its front matter and path_pattern; a path passed explicitly must agree with it unless on_mismatch is warn. Creates directories if needed. Refuses to replace an existing file unless on_conflict says otherwise. On first use, asks for root_path (e.g., /Users/yourname/blog) and saves it to config.",
+		Annotations: writeTool,
+		Output:      SaveOutput{},
+		Handler:     handler(HandleBcktSave),
+	})
+}
+
+func HandleBcktSave(ctx context.Context, id interface{}, args SaveArgs, store *ConfigStore) *Response {
+	if args.Markdown == "" {
+		return invalidArgument(id, "/markdown", "must not be empty", "Pass the markdown from bckt or bckt_preview")
+	}
+
+	cfg := store.Get()
+
+	// Work out where the front matter says the post belongs, with the
+	// patterns of the inline config the post was formatted with, if any
+	patterns := cfg
+	if args.Config != "" {
+		if err := toml.Unmarshal([]byte(args.Config), &patterns); err != nil {
+			return invalidArgument(id, "/config", fmt.Sprintf("invalid TOML: %v", err), "Pass the same config that was passed to bckt, or leave config out")
+		}
+		patterns.RootPath, patterns.Save = cfg.RootPath, cfg.Save
+	}
+	computed, computeErr := savePaths(patterns, args.Markdown)
+	explicit := args.Path != ""
+	if !explicit {
+		if computeErr != nil {
+			return invalidArgument(id, "/markdown", computeErr.Error(), "Format the post with bckt or bckt_preview first, or pass path")
+		}
+		args.Path = computed[0]
 	}
 
-	if params.Arguments != nil {
-		if err := json.Unmarshal(*params.Arguments, &args); err != nil {
+	// If path is relative, we need root_path
+	if !filepath.IsAbs(expandPath(args.Path)) && cfg.RootPath == "" {
+		if args.RootPath == "" {
+			// Not provided in arguments either - suggest setup
+			return invalidArgument(id, "/root_path", "root_path is not configured",
+				"Run bckt_setup first to configure root_path, timezone, and other settings interactively, or pass root_path")
+		}
+
+		// Save the provided root_path to config
+		err := store.Update(func(cfg *Config) error {
+			cfg.RootPath = args.RootPath
+			return SaveGlobalConfig(GlobalConfigPath(), cfg)
+		})
+		if err != nil {
 			return &Response{
 				JSONRPC: "2.0",
 				ID:      id,
-				Error:   &Error{Code: -32602, Message: "Invalid arguments"},
+				Error:   &Error{Code: 1, Message: fmt.Sprintf("Failed to save root_path to config: %v", err)},
 			}
 		}
+		cfg.RootPath = args.RootPath
+		patterns.RootPath = args.RootPath
 	}
 
-	if args.Markdown == "" || args.Path == "" {
-		return &Response{
-			JSONRPC: "2.0",
-			ID:      id,
-			Error:   &Error{Code: -32602, Message: "markdown and path are required"},
+	// Never write outside root_path, whatever the path or its symlinks say
+	finalPath, err := confinePath(cfg, args.Path)
+	if err != nil {
+		hint := "Save under root_path, using the path from bckt or bckt_preview"
+		if filepath.IsAbs(expandPath(args.Path)) {
+			hint += ", or add the directory to [save] allowed_dirs in the config"
 		}
+		return invalidArgument(id, "/path", err.Error(), hint)
 	}
 
-	// Determine the final path
-	var finalPath string
-	pathIsRelative := !filepath.IsAbs(args.Path)
-
-	// If path is relative, we need root_path
-	if pathIsRelative {
-		currentRootPath := ""
-		if globalConfig != nil {
-			currentRootPath = globalConfig.RootPath
+	// A path given by the caller has to agree with the front matter. When
+	// the front matter gives no path, there is nothing to check against
+	var warnings []string
+	switch {
+	case !explicit:
+	case computeErr != nil:
+		warnings = append(warnings, fmt.Sprintf("path not checked against the front matter: %v", computeErr))
+	case !matchesAny(cfg, finalPath, computed):
+		mismatch := fmt.Sprintf("path %s does not match the front matter, which gives %s", finalPath, computed[0])
+		if args.OnMismatch != "warn" {
+			return invalidArgument(id, "/path", mismatch, "Leave path out to save where the front matter says, pass the config given to bckt if it set other patterns, or set on_mismatch to \"warn\" to save at path anyway")
 		}
+		warnings = append(warnings, mismatch)
+	}
 
-		// Check if root_path is configured
-		if currentRootPath == "" {
-			// Root path not configured
-			if args.RootPath == "" {
-				// Not provided in arguments either - suggest setup
+	// Handle an existing file at the target path
+	markdown := args.Markdown
+	note := ""
+	output := SaveOutput{Status: "saved"}
+	if existing, err := os.ReadFile(finalPath); err == nil {
+		switch {
+		case string(existing) == markdown:
+			content := []Content{
+				{Type: "text", Text: fmt.Sprintf("✓ Already saved, no changes: %s", finalPath)},
+			}
+			return &Response{
+				JSONRPC: "2.0",
+				ID:      id,
+				Result:  ToolCallResult{Content: content, StructuredContent: SaveOutput{Path: finalPath, Status: "unchanged", Warnings: warnings}},
+			}
+		case args.OnConflict == "diff":
+			diff := unifiedDiff(finalPath, finalPath+" (new)", string(existing), markdown)
+			content := []Content{
+				{Type: "text", Text: fmt.Sprintf("File already exists: %s\nNot saved. Changes that saving would make:", finalPath)},
+				{Type: "text", Text: diff},
+			}
+			return &Response{
+				JSONRPC: "2.0",
+				ID:      id,
+				Result:  ToolCallResult{Content: content, StructuredContent: SaveOutput{Path: finalPath, Status: "diff", Existing: finalPath, Diff: diff, Warnings: warnings}},
+			}
+		case args.OnConflict == "overwrite":
+			note = " (overwritten)"
+			output.Status = "overwritten"
+		case args.OnConflict == "suffix":
+			newPath, newMarkdown, err := suffixPost(patterns, finalPath, markdown)
+			if err != nil {
 				return &Response{
 					JSONRPC: "2.0",
 					ID:      id,
-					Error:   &Error{Code: -32602, Message: "Configuration not set up. Please run bckt_setup first to configure root_path, timezone, and other settings interactively."},
+					Error:   &Error{Code: 1, Message: err.Error()},
 				}
 			}
-
-			// Save the provided root_path to config
-			if globalConfig == nil {
-				cfg := GetDefaultConfig()
-				globalConfig = &cfg
-			}
-			globalConfig.RootPath = args.RootPath
-			homeDir, _ := os.UserHomeDir()
-			configPath := filepath.Join(homeDir, ".config", "bckt-mcp", "config.toml")
-			if err := SaveGlobalConfig(configPath, globalConfig); err != nil {
+			// The free path may lead somewhere else through a symlink
+			if _, err := confinePath(cfg, newPath); err != nil {
 				return &Response{
 					JSONRPC: "2.0",
 					ID:      id,
-					Error:   &Error{Code: 1, Message: fmt.Sprintf("Failed to save root_path to config: %v", err)},
+					Error:   &Error{Code: 1, Message: err.Error()},
 				}
 			}
-			currentRootPath = args.RootPath
+			note = fmt.Sprintf(" (%s already exists)", finalPath)
+			output.Status, output.Existing = "suffixed", finalPath
+			finalPath, markdown = newPath, newMarkdown
+		default:
+			return fileExists(id, finalPath)
 		}
+	}
 
-		// Build absolute path
-		finalPath = filepath.Join(currentRootPath, args.Path)
-	} else {
-		// Path is already absolute
-		finalPath = args.Path
+	if stop := stopIfDone(ctx, id); stop != nil {
+		return stop
 	}
 
 	// Create directories if needed
@@ -90,8 +163,16 @@ func HandleBcktSave(id interface{}, params ToolCallParams, globalConfig *Config)
 		}
 	}
 
-	// Write file
-	if err := os.WriteFile(finalPath, []byte(args.Markdown), 0644); err != nil {
+	// Write file. A new post is created exclusively, so a concurrent save of
+	// the same path can't silently replace it
+	write := createPost
+	if output.Status == "overwritten" {
+		write = writePost
+	}
+	if err := write(cfg, finalPath, []byte(markdown)); err != nil {
+		if os.IsExist(err) {
+			return fileExists(id, finalPath)
+		}
 		return &Response{
 			JSONRPC: "2.0",
 			ID:      id,
@@ -100,12 +181,115 @@ func HandleBcktSave(id interface{}, params ToolCallParams, globalConfig *Config)
 	}
 
 	content := []Content{
-		{Type: "text", Text: fmt.Sprintf("✓ Saved to: %s", finalPath)},
+		{Type: "text", Text: fmt.Sprintf("✓ Saved to: %s%s", finalPath, note)},
 	}
+	if len(warnings) > 0 {
+		content = append(content, Content{Type: "text", Text: "Warnings:\n- " + strings.Join(warnings, "\n- ")})
+	}
+	output.Path = finalPath
+	output.Warnings = warnings
 
 	return &Response{
 		JSONRPC: "2.0",
 		ID:      id,
-		Result:  ToolCallResult{Content: content},
+		Result:  ToolCallResult{Content: content, StructuredContent: output},
+	}
+}
+
+// fileExists refuses to replace an existing file, as an isError result so
+// the model can pick a conflict mode and call bckt_save again.
+func fileExists(id interface{}, path string) *Response {
+	verr := &ValidationError{}
+	verr.add("/path", "file already exists: %s", path).Hint =
+		"Call bckt_save again with on_conflict set to \"diff\" to see the changes, \"overwrite\" to replace it, or \"suffix\" to save under a new slug"
+	return problemsFound(id, "File already exists, nothing was saved:", verr)
+}
+
+// savePaths computes the path of a post from the front matter in its
+// markdown, as bckt does when formatting it. A dated post may also be at
+// its scheduled or its live path, since which one bckt picked depends on
+// when it was formatted; those follow the path it gets now.
+func savePaths(cfg Config, markdown string) ([]string, error) {
+	frontMatter, _, err := parsePost([]byte(markdown))
+	if err != nil {
+		return nil, err
+	}
+	if len(frontMatter) == 0 {
+		return nil, fmt.Errorf("the markdown has no front matter to compute the path from")
+	}
+
+	path, err := computePostPath(cfg, frontMatter)
+	if err != nil {
+		return nil, err
+	}
+	paths := []string{path}
+	if !isDraft(frontMatter) {
+		for _, pattern := range []string{cfg.PathPattern, cfg.scheduledPattern()} {
+			if rel, err := renderPattern(cfg, pattern, frontMatter); err == nil {
+				paths = append(paths, filepath.Join(cfg.RootPath, rel))
+			}
+		}
+	}
+	return paths, nil
+}
+
+// matchesAny reports whether path is one of the candidate paths once both
+// are resolved the way confinePath resolves them.
+func matchesAny(cfg Config, path string, candidates []string) bool {
+	for _, candidate := range candidates {
+		if resolved, err := confinePath(cfg, candidate); err == nil && resolved == path {
+			return true
+		}
 	}
+	return false
+}
+
+// suffixPost finds a free path by appending -2, -3… to the slug in the front
+// matter and computing the path from it again, as bckt would for a post with
+// that slug. A path that doesn't follow from the front matter, or a post
+// without a slug, only gets its file name suffixed.
+func suffixPost(cfg Config, path, markdown string) (string, string, error) {
+	frontMatter, body, err := parsePost([]byte(markdown))
+	if err != nil {
+		return "", "", err
+	}
+	slug, _ := frontMatter["slug"].(string)
+
+	// Which of the paths the front matter allows the post is at, if any
+	match := -1
+	if computed, err := savePaths(cfg, markdown); err == nil && slug != "" {
+		for i, candidate := range computed {
+			if resolved, err := confinePath(cfg, candidate); err == nil && resolved == path {
+				match = i
+				break
+			}
+		}
+	}
+
+	for n := 2; n < 1000; n++ {
+		candidate, newMarkdown := "", markdown
+		if match < 0 {
+			ext := filepath.Ext(path)
+			candidate = fmt.Sprintf("%s-%d%s", strings.TrimSuffix(path, ext), n, ext)
+		} else {
+			frontMatter["slug"] = fmt.Sprintf("%s-%d", slug, n)
+			if newMarkdown, err = rewritePost(markdown, frontMatter, body); err != nil {
+				return "", "", err
+			}
+			computed, err := savePaths(cfg, newMarkdown)
+			if err != nil || match >= len(computed) {
+				return "", "", fmt.Errorf("cannot compute the path for slug %s: %v", frontMatter["slug"], err)
+			}
+			if candidate, err = confinePath(cfg, computed[match]); err != nil {
+				return "", "", err
+			}
+		}
+
+		if _, err := os.Stat(candidate); !os.IsNotExist(err) {
+			continue
+		}
+		return candidate, newMarkdown, nil
+	}
+
+	return "", "", fmt.Errorf("could not find a free path for %s", path)
 }
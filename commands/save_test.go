@@ -0,0 +1,167 @@
+package commands
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func testPost(slug, body string) string {
+	return "---\ntitle: A Post\nslug: \"" + slug + "\"\ndate: 2025-10-07 09:30:00 +0000\ntags: []\nabstract: \"\"\nlang: en\n---\n\n" + body + "\n"
+}
+
+func TestSaveSuffix(t *testing.T) {
+	tests := []struct {
+		name     string
+		slug     string
+		existing []string // Files already there, relative to root_path
+		path     string   // Passed explicitly, with on_mismatch warn
+		want     string
+		wantSlug string
+	}{
+		{
+			name:     "slug in the directory names",
+			slug:     "post",
+			existing: []string{"posts/2025/2025-10-07-post/post.md"},
+			want:     "posts/2025/2025-10-07-post-2/post-2.md",
+			wantSlug: "post-2",
+		},
+		{
+			name:     "slug equal to the year",
+			slug:     "2025",
+			existing: []string{"posts/2025/2025-10-07-2025/2025.md"},
+			want:     "posts/2025/2025-10-07-2025-2/2025-2.md",
+			wantSlug: "2025-2",
+		},
+		{
+			name:     "next free slug",
+			slug:     "post",
+			existing: []string{"posts/2025/2025-10-07-post/post.md", "posts/2025/2025-10-07-post-2/post-2.md"},
+			want:     "posts/2025/2025-10-07-post-3/post-3.md",
+			wantSlug: "post-3",
+		},
+		{
+			name:     "path not from the front matter",
+			slug:     "post",
+			existing: []string{"notes/post.md"},
+			path:     "notes/post.md",
+			want:     "notes/post-2.md",
+			wantSlug: "post",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			store, root := newTestBlog(t)
+			for _, rel := range tt.existing {
+				writeTestPost(t, root, rel, "existing\n")
+			}
+
+			args := map[string]interface{}{"markdown": testPost(tt.slug, "New."), "on_conflict": "suffix"}
+			if tt.path != "" {
+				args["path"], args["on_mismatch"] = tt.path, "warn"
+			}
+			result := toolResult(t, callTool(t, store, "bckt_save", args))
+
+			output := result.StructuredContent.(SaveOutput)
+			want := filepath.Join(root, filepath.FromSlash(tt.want))
+			if output.Path != want || output.Status != "suffixed" {
+				t.Fatalf("saved to %s (%s), want %s (suffixed)", output.Path, output.Status, want)
+			}
+			saved := readTestFile(t, want)
+			if !strings.Contains(saved, "slug: \""+tt.wantSlug+"\"") && !strings.Contains(saved, "slug: "+tt.wantSlug+"\n") {
+				t.Errorf("saved front matter does not have slug %s:\n%s", tt.wantSlug, saved)
+			}
+			for _, rel := range tt.existing {
+				if got := readTestFile(t, filepath.Join(root, rel)); got != "existing\n" {
+					t.Errorf("%s was changed to %q", rel, got)
+				}
+			}
+			if _, err := os.Stat(filepath.Join(root, "post-2s")); err == nil {
+				t.Errorf("a stray post-2s directory was created")
+			}
+		})
+	}
+}
+
+func TestSaveConflict(t *testing.T) {
+	rel := "posts/2025/2025-10-07-post/post.md"
+	markdown := testPost("post", "New.")
+
+	tests := []struct {
+		onConflict string
+		existing   string
+		wantStatus string // Empty when the save is refused
+		wantFile   string
+	}{
+		{"", "", "saved", markdown},
+		{"", "existing\n", "", "existing\n"},
+		{"error", "existing\n", "", "existing\n"},
+		{"overwrite", "existing\n", "overwritten", markdown},
+		{"diff", "existing\n", "diff", "existing\n"},
+		{"error", markdown, "unchanged", markdown},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.onConflict+"/"+tt.wantStatus, func(t *testing.T) {
+			store, root := newTestBlog(t)
+			path := filepath.Join(root, rel)
+			if tt.existing != "" {
+				writeTestPost(t, root, rel, tt.existing)
+			}
+
+			args := map[string]interface{}{"markdown": markdown}
+			if tt.onConflict != "" {
+				args["on_conflict"] = tt.onConflict
+			}
+			response := callTool(t, store, "bckt_save", args)
+			if tt.wantStatus == "" {
+				if text := toolError(t, response); !strings.Contains(text, "already exists") {
+					t.Errorf("refusal does not say the file exists: %s", text)
+				}
+			} else {
+				output := toolResult(t, response).StructuredContent.(SaveOutput)
+				if output.Status != tt.wantStatus || output.Path != path {
+					t.Errorf("status %s at %s, want %s at %s", output.Status, output.Path, tt.wantStatus, path)
+				}
+				if tt.wantStatus == "diff" && !strings.Contains(output.Diff, "+New.") {
+					t.Errorf("diff does not show the new text:\n%s", output.Diff)
+				}
+			}
+			if got := readTestFile(t, path); got != tt.wantFile {
+				t.Errorf("file holds %q, want %q", got, tt.wantFile)
+			}
+		})
+	}
+}
+
+func TestSaveConcurrentCreate(t *testing.T) {
+	store, root := newTestBlog(t)
+
+	const n = 8
+	results := make(chan *Response, n)
+	for i := 0; i < n; i++ {
+		go func(i int) {
+			markdown := testPost("post", strings.Repeat("x", i+1))
+			results <- callTool(t, store, "bckt_save", map[string]interface{}{"markdown": markdown})
+		}(i)
+	}
+
+	saved := 0
+	for i := 0; i < n; i++ {
+		result, _ := (<-results).Result.(ToolCallResult)
+		if !result.IsError {
+			saved++
+		}
+	}
+	if saved != 1 {
+		t.Errorf("%d saves succeeded, want exactly 1", saved)
+	}
+
+	// Whichever save won, its post is there in full
+	entries, err := os.ReadDir(filepath.Join(root, "posts/2025/2025-10-07-post"))
+	if err != nil || len(entries) != 1 {
+		t.Fatalf("post directory holds %v (%v), want only the post", entries, err)
+	}
+}
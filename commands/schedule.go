@@ -0,0 +1,138 @@
+package commands
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+)
+
+func init() {
+	registerTool(&Tool{
+		Name:        "bckt_publish_due",
+		Title:       "Publish Due Posts",
+		Description: "Move scheduled posts whose date has passed from the scheduled queue (scheduled_pattern) to their place under path_pattern, and list the posts still waiting. Posts dated in the future are put in the queue by bckt and bckt_publish. The same runs from cron with `bckt-mcp publish-due`.",
+		Annotations: idempotentWriteTool,
+		Output:      PublishDueOutput{},
+		Handler:     handler(HandleBcktPublishDue),
+	})
+}
+
+func HandleBcktPublishDue(ctx context.Context, id interface{}, args PublishDueArgs, store *ConfigStore) *Response {
+	output, err := PublishDue(ctx, store.Get(), time.Now(), args.Preview)
+	if err != nil {
+		return &Response{
+			JSONRPC: "2.0",
+			ID:      id,
+			Error:   &Error{Code: 1, Message: err.Error()},
+		}
+	}
+
+	content := []Content{
+		{Type: "text", Text: FormatPublishDue(output)},
+	}
+
+	return &Response{
+		JSONRPC: "2.0",
+		ID:      id,
+		Result:  ToolCallResult{Content: content, StructuredContent: output},
+	}
+}
+
+// PublishDue moves the scheduled posts dated at or before now into place.
+// A post that can't be moved is reported in Failed and the rest still go.
+func PublishDue(ctx context.Context, cfg Config, now time.Time, preview bool) (*PublishDueOutput, error) {
+	posts, err := scanScheduled(ctx, cfg)
+	if err != nil {
+		return nil, err
+	}
+
+	// Oldest first, so posts go live in the order they were meant to
+	loc := cfg.location()
+	sort.SliceStable(posts, func(i, j int) bool { return postBefore(loc, posts[i], posts[j]) })
+
+	output := &PublishDueOutput{
+		Preview:   preview,
+		Published: []ScheduledPost{},
+		Pending:   []ScheduledPost{},
+	}
+
+	for _, post := range posts {
+		entry := ScheduledPost{Title: post.Title(), From: post.Path}
+
+		date, ok := post.Date(loc)
+		if !ok {
+			entry.Error = "no valid date in the front matter"
+			output.Failed = append(output.Failed, entry)
+			continue
+		}
+		entry.Date = date.Format(dateLayout)
+
+		if date.After(now) {
+			output.Pending = append(output.Pending, entry)
+			continue
+		}
+
+		oldDir, newDir, newPath, err := planMove(cfg, post.Path, cfg.scheduledPattern(), post.FrontMatter)
+		if err != nil {
+			entry.Error = err.Error()
+			output.Failed = append(output.Failed, entry)
+			continue
+		}
+		entry.From, entry.To = oldDir, newDir
+		if !preview && ctx.Err() != nil {
+			// Out of time: leave the rest for the next run
+			entry.Error = "not moved, the call timed out or was cancelled"
+			output.Failed = append(output.Failed, entry)
+			continue
+		}
+		if !preview {
+			if err := applyMove(cfg, post.Path, oldDir, newDir, newPath, post.Raw); err != nil {
+				entry.Error = err.Error()
+				output.Failed = append(output.Failed, entry)
+				continue
+			}
+		}
+		output.Published = append(output.Published, entry)
+	}
+
+	return output, nil
+}
+
+// FormatPublishDue describes the result of PublishDue for people.
+func FormatPublishDue(output *PublishDueOutput) string {
+	var b strings.Builder
+	if output.Preview {
+		b.WriteString("PREVIEW MODE - Not saved\n")
+	}
+
+	if len(output.Published) == 0 {
+		b.WriteString("No scheduled posts are due.\n")
+	} else {
+		verb := "Published"
+		if output.Preview {
+			verb = "Due"
+		}
+		fmt.Fprintf(&b, "%s (%d):\n", verb, len(output.Published))
+		for _, entry := range output.Published {
+			fmt.Fprintf(&b, "  %s  %s\n    %s → %s\n", entry.Date, entry.Title, entry.From, entry.To)
+		}
+	}
+
+	if len(output.Failed) > 0 {
+		fmt.Fprintf(&b, "\nFailed (%d):\n", len(output.Failed))
+		for _, entry := range output.Failed {
+			fmt.Fprintf(&b, "  %s: %s\n", entry.From, entry.Error)
+		}
+	}
+
+	if len(output.Pending) > 0 {
+		fmt.Fprintf(&b, "\nStill scheduled (%d):\n", len(output.Pending))
+		for _, entry := range output.Pending {
+			fmt.Fprintf(&b, "  %s  %s\n", entry.Date, entry.Title)
+		}
+	}
+
+	return b.String()
+}
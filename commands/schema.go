@@ -0,0 +1,343 @@
+package commands
+
+import (
+	"fmt"
+	"math"
+	"regexp"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+	"unicode/utf8"
+)
+
+// FieldError is a single validation problem with a tool argument or a
+// front matter field.
+type FieldError struct {
+	Pointer  string `json:"pointer"`            // JSON pointer to the value, e.g. "/meta/tags/2"
+	Message  string `json:"message"`            // What is wrong
+	Expected string `json:"expected,omitempty"` // The expected type, for type errors
+	Hint     string `json:"hint,omitempty"`     // How to fix it
+}
+
+// ValidationError lists every problem found at once, so they can all be
+// fixed in one retry.
+type ValidationError struct {
+	Errors []FieldError `json:"errors"`
+}
+
+func (e *ValidationError) Error() string {
+	var parts []string
+	for _, fe := range e.Errors {
+		parts = append(parts, fmt.Sprintf("%s: %s", displayPointer(fe.Pointer), fe.Message))
+	}
+	return "invalid arguments: " + strings.Join(parts, "; ")
+}
+
+func (e *ValidationError) add(pointer, format string, args ...interface{}) *FieldError {
+	e.Errors = append(e.Errors, FieldError{Pointer: pointer, Message: fmt.Sprintf(format, args...)})
+	return &e.Errors[len(e.Errors)-1]
+}
+
+// typeError records a value of the wrong type.
+func (e *ValidationError) typeError(pointer, expected string, value interface{}) *FieldError {
+	fe := e.add(pointer, "expected %s, got %s", expected, typeName(value))
+	fe.Expected = expected
+	return fe
+}
+
+// under moves every error below prefix, e.g. front matter errors below "/meta".
+func (e *ValidationError) under(prefix string) *ValidationError {
+	for i := range e.Errors {
+		e.Errors[i].Pointer = prefix + e.Errors[i].Pointer
+	}
+	return e
+}
+
+// pointerJoin appends a reference token to a JSON pointer (RFC 6901).
+func pointerJoin(pointer, token string) string {
+	token = strings.ReplaceAll(token, "~", "~0")
+	token = strings.ReplaceAll(token, "/", "~1")
+	return pointer + "/" + token
+}
+
+// displayPointer shows the empty pointer, which refers to the whole
+// document, in a readable way.
+func displayPointer(pointer string) string {
+	if pointer == "" {
+		return "arguments"
+	}
+	return pointer
+}
+
+// defaultSchema describes the fields bckt-mcp generates itself.
+func defaultSchema() map[string]FieldSchema {
+	return map[string]FieldSchema{
+		"title":    {Type: "string"},
+		"slug":     {Type: "string", Pattern: `^[^/\\.][^/\\]*$`, Auto: boolPtr(true)},
+		"date":     {Type: "date", Auto: boolPtr(true)},
+		"tags":     {Type: "list"},
+		"abstract": {Type: "string"},
+		"lang":     {Type: "string", Pattern: `^[a-z]{2,3}(-[A-Za-z0-9]+)*$`},
+		"aliases":  {Type: "list"},
+		"draft":    {Type: "bool"},
+	}
+}
+
+// frontMatterSchema returns the default schema overlaid with the fields
+// configured in [front_matter.schema]. A configured field only overrides the
+// keys it sets, so max_length = 60 on slug keeps its pattern and auto.
+func frontMatterSchema(cfg Config) map[string]FieldSchema {
+	schema := defaultSchema()
+	for name, field := range cfg.FrontMatter.Schema {
+		schema[name] = schema[name].merge(field)
+	}
+	return schema
+}
+
+// merge returns f with the keys set in other replacing its own.
+func (f FieldSchema) merge(other FieldSchema) FieldSchema {
+	if other.Type != "" {
+		f.Type = other.Type
+	}
+	if other.Enum != nil {
+		f.Enum = other.Enum
+	}
+	if other.Pattern != "" {
+		f.Pattern = other.Pattern
+	}
+	if other.MaxLength != 0 {
+		f.MaxLength = other.MaxLength
+	}
+	if other.Auto != nil {
+		f.Auto = other.Auto
+	}
+	return f
+}
+
+// auto reports whether the field is generated when missing.
+func (f FieldSchema) auto() bool {
+	return f.Auto != nil && *f.Auto
+}
+
+// schemaTypes are the values of type in [front_matter.schema].
+var schemaTypes = []string{"string", "list", "date", "bool", "int"}
+
+// checkSchema validates [front_matter.schema], so that a bad type or pattern
+// is reported when the config is loaded rather than on every post.
+func (c Config) checkSchema() *ValidationError {
+	names := make([]string, 0, len(c.FrontMatter.Schema))
+	for name := range c.FrontMatter.Schema {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	verr := &ValidationError{}
+	for _, name := range names {
+		field := c.FrontMatter.Schema[name]
+		pointer := pointerJoin("/front_matter/schema", name)
+		if field.Type != "" && !contains(schemaTypes, field.Type) {
+			verr.add(pointer+"/type", "unknown type %q", field.Type).Hint =
+				"Use one of: " + strings.Join(schemaTypes, ", ")
+		}
+		if field.Pattern != "" {
+			if _, err := schemaPattern(field.Pattern); err != nil {
+				verr.add(pointer+"/pattern", "invalid pattern: %v", err).Hint =
+					"Use a Go regular expression, e.g. \"^[a-z0-9-]+$\""
+			}
+		}
+		if field.MaxLength < 0 {
+			verr.add(pointer+"/max_length", "must not be negative, got %d", field.MaxLength)
+		}
+	}
+	if len(verr.Errors) > 0 {
+		return verr
+	}
+	return nil
+}
+
+// schemaPatterns caches compiled schema patterns, which are checked when the
+// config is loaded and then matched against every post.
+var schemaPatterns sync.Map // pattern -> *regexp.Regexp
+
+func schemaPattern(pattern string) (*regexp.Regexp, error) {
+	if re, ok := schemaPatterns.Load(pattern); ok {
+		return re.(*regexp.Regexp), nil
+	}
+	re, err := regexp.Compile(pattern)
+	if err != nil {
+		return nil, err
+	}
+	schemaPatterns.Store(pattern, re)
+	return re, nil
+}
+
+// validateFrontMatter checks the front matter against the required fields and
+// the schema, reporting every violation at once. Fields that are neither
+// required nor in the schema are rejected in strict mode and reported as
+// warnings otherwise.
+func validateFrontMatter(fm map[string]interface{}, cfg Config, strict bool) ([]string, error) {
+	schema := frontMatterSchema(cfg)
+	verr := &ValidationError{}
+
+	required := make(map[string]bool)
+	for _, field := range cfg.FrontMatter.Required {
+		required[field] = true
+		if _, ok := fm[field]; !ok {
+			verr.add(pointerJoin("", field), "missing required field").Hint =
+				fmt.Sprintf("Add %s, or remove it from front_matter.required in the config", field)
+		}
+	}
+
+	keys := make([]string, 0, len(fm))
+	for key := range fm {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	var warnings []string
+	for _, key := range keys {
+		field, known := schema[key]
+		if !known {
+			if required[key] {
+				continue
+			}
+			if strict {
+				verr.add(pointerJoin("", key), "unknown field in strict mode").Hint =
+					fmt.Sprintf("Remove %s, use strategy \"lenient\", or describe it in [front_matter.schema]", key)
+			} else {
+				warnings = append(warnings, fmt.Sprintf("unknown field: %s", key))
+			}
+			continue
+		}
+		validateField(verr, pointerJoin("", key), fm[key], field)
+	}
+
+	if len(verr.Errors) > 0 {
+		return nil, verr
+	}
+	return warnings, nil
+}
+
+func validateField(verr *ValidationError, path string, value interface{}, field FieldSchema) {
+	switch field.Type {
+	case "", "string":
+		s, ok := value.(string)
+		if !ok {
+			verr.typeError(path, "string", value)
+			return
+		}
+		validateString(verr, path, s, field)
+
+	case "list":
+		var items []interface{}
+		switch v := value.(type) {
+		case []interface{}:
+			items = v
+		case []string:
+			for _, item := range v {
+				items = append(items, item)
+			}
+		default:
+			verr.typeError(path, "list", value).Hint = "Use a list of strings, e.g. [\"go\", \"mcp\"]"
+			return
+		}
+		for i, item := range items {
+			itemPath := pointerJoin(path, fmt.Sprint(i))
+			s, ok := item.(string)
+			if !ok {
+				verr.typeError(itemPath, "string", item)
+				continue
+			}
+			validateString(verr, itemPath, s, field)
+		}
+
+	case "date":
+		// Only whether it parses matters here, not the timezone
+		if _, ok := parseDateValue(value, time.UTC); !ok {
+			fe := verr.add(path, "expected a date, got %v", value)
+			fe.Expected = "date"
+			fe.Hint = dateHint + ", or leave it out to use the current time"
+		}
+
+	case "bool":
+		if _, ok := value.(bool); !ok {
+			verr.typeError(path, "boolean", value).Hint = "Use true or false"
+		}
+
+	case "int":
+		switch v := value.(type) {
+		case int, int64, uint64:
+		case float64:
+			if v != math.Trunc(v) {
+				verr.add(path, "expected an integer, got %v", v).Expected = "integer"
+			}
+		default:
+			verr.typeError(path, "integer", value)
+		}
+
+	default:
+		verr.add(path, "schema has unknown type %q", field.Type)
+	}
+}
+
+func validateString(verr *ValidationError, path, s string, field FieldSchema) {
+	if len(field.Enum) > 0 {
+		allowed := false
+		for _, e := range field.Enum {
+			if s == e {
+				allowed = true
+				break
+			}
+		}
+		if !allowed {
+			verr.add(path, "must be one of %s, got %q", strings.Join(field.Enum, ", "), s).Hint =
+				"Use one of: " + strings.Join(field.Enum, ", ")
+		}
+	}
+
+	if field.Pattern != "" {
+		re, err := schemaPattern(field.Pattern)
+		if err != nil {
+			verr.add(path, "schema has an invalid pattern %q: %v", field.Pattern, err)
+		} else if !re.MatchString(s) {
+			verr.add(path, "must match pattern %s, got %q", field.Pattern, s).Hint = patternHint(path)
+		}
+	}
+
+	if field.MaxLength > 0 {
+		if n := utf8.RuneCountInString(strings.Join(strings.Fields(s), " ")); n > field.MaxLength {
+			verr.add(path, "must be at most %d characters, got %d", field.MaxLength, n).Hint =
+				fmt.Sprintf("Shorten it to %d characters or fewer", field.MaxLength)
+		}
+	}
+}
+
+// patternHint explains the patterns of the built-in fields.
+func patternHint(pointer string) string {
+	switch pointer {
+	case "/slug":
+		return "Use lowercase letters, digits and dashes, e.g. \"my-first-post\""
+	case "/lang":
+		return "Use a language code like \"en\", \"el\" or \"pt-BR\""
+	}
+	return ""
+}
+
+func typeName(value interface{}) string {
+	switch value.(type) {
+	case nil:
+		return "null"
+	case string:
+		return "a string"
+	case bool:
+		return "a boolean"
+	case int, int64, uint64, float64:
+		return "a number"
+	case []interface{}, []string:
+		return "a list"
+	case map[string]interface{}:
+		return "an object"
+	}
+	return fmt.Sprintf("%T", value)
+}
@@ -0,0 +1,80 @@
+package commands
+
+import (
+	"context"
+	"strings"
+	"testing"
+)
+
+func TestFormatContentSchema(t *testing.T) {
+	tests := []struct {
+		name     string
+		config   string
+		title    string
+		wantSlug string // Expected in the front matter; empty when formatting fails
+		wantErr  string // Pointer of the expected error
+	}{
+		{
+			name:     "built-in slug",
+			title:    "Hello World",
+			wantSlug: "slug: hello-world",
+		},
+		{
+			name:     "max_length keeps auto and pattern",
+			config:   "[front_matter.schema.slug]\nmax_length = 60",
+			title:    "Hello World",
+			wantSlug: "slug: hello-world",
+		},
+		{
+			name:    "max_length applies",
+			config:  "[front_matter.schema.slug]\nmax_length = 5",
+			title:   "Hello World",
+			wantErr: "/meta/slug",
+		},
+		{
+			name:    "auto turned off",
+			config:  "front_matter.required = [\"slug\"]\n[front_matter.schema.slug]\nauto = false",
+			title:   "Hello World",
+			wantErr: "/meta/slug",
+		},
+		{
+			name:    "unknown type",
+			config:  "[front_matter.schema.rating]\ntype = \"number\"",
+			title:   "Hello World",
+			wantErr: "/config/front_matter/schema/rating/type",
+		},
+		{
+			name:    "invalid pattern",
+			config:  "[front_matter.schema.slug]\npattern = \"[a-z\"",
+			title:   "Hello World",
+			wantErr: "/config/front_matter/schema/slug/pattern",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cfg := GetDefaultConfig()
+			cfg.Timezone = "UTC"
+			input := FormatInput{
+				Raw:    "Body.",
+				Meta:   PostMeta{"title": tt.title, "lang": "en"},
+				Config: tt.config,
+			}
+			output, err := FormatContent(context.Background(), input, cfg)
+
+			if tt.wantErr != "" {
+				verr, ok := err.(*ValidationError)
+				if !ok || len(verr.Errors) == 0 || verr.Errors[0].Pointer != tt.wantErr {
+					t.Fatalf("FormatContent() error = %v, want one at %s", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("FormatContent() error = %v", err)
+			}
+			if !strings.Contains(output.Markdown, tt.wantSlug+"\n") {
+				t.Errorf("front matter lacks %q:\n%s", tt.wantSlug, output.Markdown)
+			}
+		})
+	}
+}
@@ -1,31 +1,25 @@
 package commands
 
 import (
-	"encoding/json"
+	"context"
 	"fmt"
-	"os"
-	"path/filepath"
 )
 
-func HandleBcktSetup(id interface{}, params ToolCallParams, globalConfig **Config) *Response {
-	var args struct {
-		RootPath    string `json:"root_path"`
-		Timezone    string `json:"timezone"`
-		PathPattern string `json:"path_pattern,omitempty"`
-		WrapAt      int    `json:"wrap_at,omitempty"`
-		Confirm     bool   `json:"confirm,omitempty"`
-	}
-
-	if params.Arguments != nil {
-		if err := json.Unmarshal(*params.Arguments, &args); err != nil {
-			return &Response{
-				JSONRPC: "2.0",
-				ID:      id,
-				Error:   &Error{Code: -32602, Message: "Invalid arguments"},
-			}
-		}
-	}
+func init() {
+	registerTool(&Tool{
+		Name:        "bckt_setup",
+		Title:       "Setup Wizard",
+		Description: "Interactive setup wizard for first-time configuration. Shows current values and suggestions, then saves all settings at once when confirmed.",
+		Annotations: idempotentWriteTool,
+		Output:      SetupOutput{},
+		Handler:     handler(HandleBcktSetup),
+		Complete: map[string]Completer{
+			"/timezone": CompleteTimezones,
+		},
+	})
+}
 
+func HandleBcktSetup(ctx context.Context, id interface{}, args SetupArgs, store *ConfigStore) *Response {
 	// Load defaults
 	defaults := GetDefaultConfig()
 
@@ -36,11 +30,22 @@ func HandleBcktSetup(id interface{}, params ToolCallParams, globalConfig **Confi
 	if pathPattern == "" {
 		pathPattern = defaults.PathPattern
 	}
+	if _, err := parsePattern(pathPattern); err != nil {
+		return invalidArgument(id, "/path_pattern", err.Error(), "Use the placeholders "+patternHelp)
+	}
 	wrapAt := args.WrapAt
 	if wrapAt == 0 {
 		wrapAt = defaults.MarkdownRule.WrapAt
 	}
 
+	output := SetupOutput{
+		ConfigPath:  GlobalConfigPath(),
+		RootPath:    rootPath,
+		Timezone:    timezone,
+		PathPattern: pathPattern,
+		WrapAt:      wrapAt,
+	}
+
 	// If not confirmed, show preview
 	if !args.Confirm {
 		previewText := fmt.Sprintf(`Configuration Preview:
@@ -53,14 +58,14 @@ timezone: %s
 
 path_pattern: %s
   → Template for file paths
-  → Placeholders: {yyyy} {MM} {DD} {slug}
+  → Placeholders: %s
   → Example: posts/2025/2025-10-07-my-post/my-post.md
 
 wrap_at: %d
   → Maximum line width for text wrapping
 
 To save this configuration, call bckt_setup again with confirm: true
-`, rootPath, timezone, pathPattern, wrapAt)
+`, rootPath, timezone, pathPattern, patternHelp, wrapAt)
 
 		content := []Content{
 			{Type: "text", Text: previewText},
@@ -69,25 +74,20 @@ To save this configuration, call bckt_setup again with confirm: true
 		return &Response{
 			JSONRPC: "2.0",
 			ID:      id,
-			Result:  ToolCallResult{Content: content},
+			Result:  ToolCallResult{Content: content, StructuredContent: output},
 		}
 	}
 
 	// Confirmed - save configuration
-	if *globalConfig == nil {
-		cfg := GetDefaultConfig()
-		*globalConfig = &cfg
-	}
-
-	(*globalConfig).RootPath = rootPath
-	(*globalConfig).Timezone = timezone
-	(*globalConfig).PathPattern = pathPattern
-	(*globalConfig).MarkdownRule.WrapAt = wrapAt
-
-	// Save to file
-	homeDir, _ := os.UserHomeDir()
-	configPath := filepath.Join(homeDir, ".config", "bckt-mcp", "config.toml")
-	if err := SaveGlobalConfig(configPath, *globalConfig); err != nil {
+	configPath := output.ConfigPath
+	err := store.Update(func(cfg *Config) error {
+		cfg.RootPath = rootPath
+		cfg.Timezone = timezone
+		cfg.PathPattern = pathPattern
+		cfg.MarkdownRule.WrapAt = wrapAt
+		return SaveGlobalConfig(configPath, cfg)
+	})
+	if err != nil {
 		return &Response{
 			JSONRPC: "2.0",
 			ID:      id,
@@ -108,10 +108,11 @@ You're all set! You can now use bckt, bckt_preview, and bckt_save.
 	content := []Content{
 		{Type: "text", Text: resultText},
 	}
+	output.Saved = true
 
 	return &Response{
 		JSONRPC: "2.0",
 		ID:      id,
-		Result:  ToolCallResult{Content: content},
+		Result:  ToolCallResult{Content: content, StructuredContent: output},
 	}
 }
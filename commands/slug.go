@@ -0,0 +1,286 @@
+package commands
+
+import (
+	"crypto/sha1"
+	"encoding/hex"
+	"fmt"
+	"sort"
+	"strings"
+	"unicode"
+)
+
+// Transliteration schemes used when no scheme is configured for a language
+var defaultTransliteration = map[string]string{
+	"el": "elot743",
+	"ru": "cyrillic",
+	"uk": "cyrillic",
+	"bg": "cyrillic",
+	"sr": "cyrillic",
+	"mk": "cyrillic",
+	"be": "cyrillic",
+	"de": "german",
+}
+
+var germanLetters = map[rune]string{
+	'ä': "ae", 'ö': "oe", 'ü': "ue", 'ß': "ss",
+}
+
+var latinLetters = map[rune]string{
+	'à': "a", 'á': "a", 'â': "a", 'ã': "a", 'ä': "a", 'å': "a", 'ā': "a", 'ă': "a", 'ą': "a",
+	'æ': "ae", 'ç': "c", 'ć': "c", 'č': "c", 'ĉ': "c", 'ċ': "c", 'ď': "d", 'đ': "d", 'ð': "d",
+	'è': "e", 'é': "e", 'ê': "e", 'ë': "e", 'ē': "e", 'ė': "e", 'ę': "e", 'ě': "e", 'ĕ': "e",
+	'ğ': "g", 'ĝ': "g", 'ġ': "g", 'ģ': "g", 'ĥ': "h", 'ħ': "h",
+	'ì': "i", 'í': "i", 'î': "i", 'ï': "i", 'ī': "i", 'į': "i", 'ı': "i", 'ĭ': "i",
+	'ĵ': "j", 'ķ': "k", 'ĺ': "l", 'ļ': "l", 'ľ': "l", 'ł': "l",
+	'ñ': "n", 'ń': "n", 'ň': "n", 'ņ': "n",
+	'ò': "o", 'ó': "o", 'ô': "o", 'õ': "o", 'ö': "o", 'ø': "o", 'ō': "o", 'ő': "o", 'ŏ': "o", 'œ': "oe",
+	'ŕ': "r", 'ř': "r", 'ŗ': "r", 'ś': "s", 'š': "s", 'ş': "s", 'ș': "s", 'ŝ': "s", 'ß': "ss",
+	'ť': "t", 'ţ': "t", 'ț': "t", 'þ': "th",
+	'ù': "u", 'ú': "u", 'û': "u", 'ü': "u", 'ū': "u", 'ů': "u", 'ű': "u", 'ų': "u", 'ŭ': "u",
+	'ŵ': "w", 'ý': "y", 'ÿ': "y", 'ŷ': "y", 'ź': "z", 'ż': "z", 'ž': "z",
+}
+
+var cyrillicLetters = map[rune]string{
+	'а': "a", 'б': "b", 'в': "v", 'г': "g", 'д': "d", 'е': "e", 'ё': "yo", 'ж': "zh",
+	'з': "z", 'и': "i", 'й': "y", 'к': "k", 'л': "l", 'м': "m", 'н': "n", 'о': "o",
+	'п': "p", 'р': "r", 'с': "s", 'т': "t", 'у': "u", 'ф': "f", 'х': "kh", 'ц': "ts",
+	'ч': "ch", 'ш': "sh", 'щ': "shch", 'ъ': "", 'ы': "y", 'ь': "", 'э': "e", 'ю': "yu",
+	'я': "ya", 'є': "ye", 'і': "i", 'ї': "yi", 'ґ': "g", 'ђ': "dj", 'ј': "j", 'љ': "lj",
+	'њ': "nj", 'ћ': "c", 'џ': "dz", 'ѓ': "gj", 'ќ': "kj", 'ѕ': "dz", 'ў': "u",
+}
+
+var greekLetters = map[rune]string{
+	'α': "a", 'β': "v", 'γ': "g", 'δ': "d", 'ε': "e", 'ζ': "z", 'η': "i", 'θ': "th",
+	'ι': "i", 'κ': "k", 'λ': "l", 'μ': "m", 'ν': "n", 'ξ': "x", 'ο': "o", 'π': "p",
+	'ρ': "r", 'σ': "s", 'ς': "s", 'τ': "t", 'υ': "y", 'φ': "f", 'χ': "ch", 'ψ': "ps",
+	'ω': "o", 'ϊ': "i", 'ΐ': "i", 'ϋ': "y", 'ΰ': "y",
+}
+
+var greekAccents = map[rune]rune{
+	'ά': 'α', 'έ': 'ε', 'ή': 'η', 'ί': 'ι', 'ό': 'ο', 'ύ': 'υ', 'ώ': 'ω',
+}
+
+// transliterationSchemes are the schemes of [slug.transliterate].
+var transliterationSchemes = []string{"elot743", "cyrillic", "german", "latin", "none"}
+
+// slugFallbacks are the values of slug.fallback.
+var slugFallbacks = []string{"unicode", "hash"}
+
+// slugify turns a title into a URL-friendly slug, transliterating non-Latin
+// scripts according to the post language. When transliteration leaves
+// letters outside ASCII, e.g. the Japanese in "Go 言語入門", the configured
+// fallback strategy is used for the whole title, and the hash when the title
+// has no letters or digits at all, e.g. only emoji.
+func slugify(s, lang string, cfg Config) (string, error) {
+	scheme := cfg.Slug.Transliterate[lang]
+	if scheme == "" {
+		scheme = defaultTransliteration[lang]
+	}
+
+	latin, err := transliterate(s, scheme)
+	if err != nil {
+		return "", err
+	}
+	if slug := asciiSlug(latin); slug != "" && !hasNonASCIILetter(latin) {
+		return slug, nil
+	}
+
+	if cfg.Slug.Fallback != "hash" {
+		if slug := unicodeSlug(s); slug != "" {
+			return slug, nil
+		}
+	}
+	sum := sha1.Sum([]byte(s))
+	return "post-" + hex.EncodeToString(sum[:])[:8], nil
+}
+
+// transliterate maps s to Latin letters. Each scheme handles its own script
+// and strips the accents of Latin letters, e.g. "ü" is "ue" in German and
+// "u" otherwise; "none" leaves s as it is. Languages without a scheme get
+// Greek, Cyrillic and Latin letters transliterated.
+func transliterate(s, scheme string) (string, error) {
+	var greek, cyrillic, german bool
+	switch scheme {
+	case "":
+		greek, cyrillic = true, true
+	case "elot743":
+		greek = true
+	case "cyrillic":
+		cyrillic = true
+	case "german":
+		german = true
+	case "latin":
+	case "none":
+		return s, nil
+	default:
+		return "", fmt.Errorf("unknown transliteration scheme %q", scheme)
+	}
+
+	s = strings.ToLower(s)
+
+	var b strings.Builder
+	runes := []rune(s)
+	for i := 0; i < len(runes); i++ {
+		r := runes[i]
+		if unicode.Is(unicode.Mn, r) {
+			continue // Combining marks, e.g. from decomposed accents
+		}
+
+		if german {
+			if t, ok := germanLetters[r]; ok {
+				b.WriteString(t)
+				continue
+			}
+		}
+		if greek {
+			if _, ok := greekLetters[r]; ok || greekAccents[r] != 0 {
+				n := transliterateGreek(runes, i, &b)
+				i += n - 1
+				continue
+			}
+		}
+		if cyrillic {
+			if t, ok := cyrillicLetters[r]; ok {
+				b.WriteString(t)
+				continue
+			}
+		}
+		if t, ok := latinLetters[r]; ok {
+			b.WriteString(t)
+			continue
+		}
+		b.WriteRune(r)
+	}
+
+	return b.String(), nil
+}
+
+// checkSlug validates the [slug] settings.
+func (c Config) checkSlug() *ValidationError {
+	verr := &ValidationError{}
+	if c.Slug.Fallback != "" && !contains(slugFallbacks, c.Slug.Fallback) {
+		verr.add("/slug/fallback", "unknown fallback %q", c.Slug.Fallback).Hint =
+			"Use one of: " + strings.Join(slugFallbacks, ", ")
+	}
+
+	langs := make([]string, 0, len(c.Slug.Transliterate))
+	for lang := range c.Slug.Transliterate {
+		langs = append(langs, lang)
+	}
+	sort.Strings(langs)
+	for _, lang := range langs {
+		if scheme := c.Slug.Transliterate[lang]; !contains(transliterationSchemes, scheme) {
+			verr.add(pointerJoin("/slug/transliterate", lang), "unknown transliteration scheme %q", scheme).Hint =
+				"Use one of: " + strings.Join(transliterationSchemes, ", ")
+		}
+	}
+
+	if len(verr.Errors) > 0 {
+		return verr
+	}
+	return nil
+}
+
+// transliterateGreek writes the ELOT 743 transliteration of the letter (or
+// digraph) at runes[i] and returns how many runes it consumed.
+func transliterateGreek(runes []rune, i int, b *strings.Builder) int {
+	at := func(j int) rune {
+		if j < 0 || j >= len(runes) {
+			return 0
+		}
+		if r, ok := greekAccents[runes[j]]; ok {
+			return r
+		}
+		return runes[j]
+	}
+	isLetter := func(r rune) bool {
+		_, ok := greekLetters[r]
+		return ok
+	}
+
+	r, next := at(i), at(i+1)
+	switch {
+	case r == 'ο' && next == 'υ':
+		b.WriteString("ou")
+		return 2
+	case (r == 'α' || r == 'ε' || r == 'η') && next == 'υ':
+		// αυ, ευ, ηυ sound as v before vowels and voiced consonants, f otherwise
+		b.WriteString(greekLetters[r])
+		if strings.ContainsRune("αεηιοωυβγδζλμνρ", at(i+2)) {
+			b.WriteString("v")
+		} else {
+			b.WriteString("f")
+		}
+		return 2
+	case r == 'γ' && next == 'γ':
+		b.WriteString("ng")
+		return 2
+	case r == 'γ' && next == 'ξ':
+		b.WriteString("nx")
+		return 2
+	case r == 'γ' && next == 'χ':
+		b.WriteString("nch")
+		return 2
+	case r == 'μ' && next == 'π':
+		// b at the start or end of a word, mp inside it
+		if !isLetter(at(i-1)) || !isLetter(at(i+2)) {
+			b.WriteString("b")
+		} else {
+			b.WriteString("mp")
+		}
+		return 2
+	}
+
+	b.WriteString(greekLetters[r])
+	return 1
+}
+
+func hasNonASCIILetter(s string) bool {
+	for _, r := range s {
+		if r > unicode.MaxASCII && unicode.IsLetter(r) {
+			return true
+		}
+	}
+	return false
+}
+
+// asciiSlug lowercases s and joins runs of [a-z0-9] with dashes.
+func asciiSlug(s string) string {
+	var b strings.Builder
+	dash := false
+	for _, r := range strings.ToLower(s) {
+		if (r >= 'a' && r <= 'z') || (r >= '0' && r <= '9') {
+			if dash && b.Len() > 0 {
+				b.WriteByte('-')
+			}
+			b.WriteRune(r)
+			dash = false
+		} else {
+			dash = true
+		}
+	}
+	return b.String()
+}
+
+// unicodeSlug is like asciiSlug but keeps letters and digits of any script.
+func unicodeSlug(s string) string {
+	var b strings.Builder
+	dash := false
+	for _, r := range strings.ToLower(s) {
+		if unicode.IsLetter(r) || unicode.IsDigit(r) || unicode.Is(unicode.Mn, r) {
+			if dash && b.Len() > 0 {
+				b.WriteByte('-')
+			}
+			b.WriteRune(r)
+			dash = false
+		} else {
+			dash = true
+		}
+	}
+	return b.String()
+}
+
+// validSlug reports whether s can be used as a slug as-is.
+func validSlug(s string) bool {
+	return s != "" && unicodeSlug(s) == s
+}
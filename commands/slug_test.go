@@ -0,0 +1,90 @@
+package commands
+
+import (
+	"context"
+	"strings"
+	"testing"
+)
+
+func TestSlugify(t *testing.T) {
+	tests := []struct {
+		title    string
+		lang     string
+		fallback string
+		scheme   string // Configured for lang
+		want     string // Empty when slugify fails
+	}{
+		{"Hello, World!", "en", "", "", "hello-world"},
+		{"  Go 1.22 is out  ", "en", "", "", "go-1-22-is-out"},
+		{"C++ -- the good parts", "en", "", "", "c-the-good-parts"},
+		{"Café crème", "fr", "", "", "cafe-creme"},
+		{"Über Größe", "de", "", "", "ueber-groesse"},
+		{"Über Größe", "en", "", "", "uber-grosse"},
+		{"Καλημέρα κόσμε", "el", "", "", "kalimera-kosme"},
+		{"Привет, мир", "ru", "", "", "privet-mir"},
+		{"日本語のタイトル", "ja", "unicode", "", "日本語のタイトル"},
+		{"日本語のタイトル", "ja", "", "", "日本語のタイトル"},
+		{"日本語のタイトル", "ja", "hash", "", "post-2eb8d0eb"},
+		{"🎉🎉 !!", "en", "unicode", "", "post-b1d75a65"},
+		{"???", "en", "", "", "post-2d86c2a6"},
+		{"Καλημέρα κόσμε", "en", "", "", "kalimera-kosme"},
+		{"Καλημέρα κόσμε", "el", "", "none", "καλημέρα-κόσμε"},
+		{"Καλημέρα κόσμε", "el", "hash", "none", "post-71b3f788"},
+		{"Über Größe", "de", "", "latin", "uber-grosse"},
+		{"Über Größe", "en", "", "german", "ueber-groesse"},
+		{"Привет, мир", "ru", "", "elot743", "привет-мир"},
+		{"Привет, мир", "ru", "", "klingon", ""},
+		{"Go 言語入門", "ja", "", "", "go-言語入門"},
+		{"Go 言語入門", "ja", "hash", "", "post-277bd99c"},
+		{"Café crème", "fr", "", "none", "café-crème"},
+		{"Hello, World!", "en", "hash", "none", "hello-world"},
+		{"🎉 Party time", "en", "hash", "", "party-time"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.title+"/"+tt.lang+"/"+tt.fallback+"/"+tt.scheme, func(t *testing.T) {
+			cfg := GetDefaultConfig()
+			cfg.Slug.Fallback = tt.fallback
+			if tt.scheme != "" {
+				cfg.Slug.Transliterate[tt.lang] = tt.scheme
+			}
+			got, err := slugify(tt.title, tt.lang, cfg)
+			if (err != nil) != (tt.want == "") {
+				t.Fatalf("slugify(%q, %q) error = %v", tt.title, tt.lang, err)
+			}
+			if got != tt.want {
+				t.Errorf("slugify(%q, %q) = %q, want %q", tt.title, tt.lang, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestCheckSlug(t *testing.T) {
+	tests := []struct {
+		config string
+		want   []string // Pointers of the expected errors
+	}{
+		{"[slug]\nfallback = \"hash\"\n[slug.transliterate]\nel = \"none\"\nde = \"latin\"", nil},
+		{"[slug]\nfallback = \"ascii\"", []string{"/config/slug/fallback"}},
+		{"[slug.transliterate]\nel = \"greek\"\nru = \"cyrillic\"", []string{"/config/slug/transliterate/el"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.config, func(t *testing.T) {
+			input := FormatInput{Raw: "Body.", Meta: PostMeta{"title": "Title"}, Config: tt.config}
+			_, err := FormatContent(context.Background(), input, GetDefaultConfig())
+
+			var got []string
+			if verr, ok := err.(*ValidationError); ok {
+				for _, fe := range verr.Errors {
+					got = append(got, fe.Pointer)
+				}
+			} else if err != nil {
+				t.Fatalf("FormatContent() error = %v", err)
+			}
+			if strings.Join(got, " ") != strings.Join(tt.want, " ") {
+				t.Errorf("errors at %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,90 @@
+package commands
+
+import "sync"
+
+// ConfigStore holds the global config and makes it safe to use from
+// concurrent requests. Readers get a private copy; writers replace it.
+type ConfigStore struct {
+	mu  sync.RWMutex
+	cfg *Config
+}
+
+func NewConfigStore(cfg *Config) *ConfigStore {
+	return &ConfigStore{cfg: cfg}
+}
+
+// Get returns a copy of the current config, or the defaults when none is loaded.
+func (s *ConfigStore) Get() Config {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	if s.cfg == nil {
+		return GetDefaultConfig()
+	}
+	return s.cfg.clone()
+}
+
+// Update applies fn to a copy of the config and keeps the result only when
+// fn succeeds, so a failed save leaves the config unchanged.
+func (s *ConfigStore) Update(fn func(cfg *Config) error) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	cfg := GetDefaultConfig()
+	if s.cfg != nil {
+		cfg = s.cfg.clone()
+	}
+	if err := fn(&cfg); err != nil {
+		return err
+	}
+	s.cfg = &cfg
+	return nil
+}
+
+// Replace swaps in a new config, e.g. after restoring config.toml.
+func (s *ConfigStore) Replace(cfg Config) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.cfg = &cfg
+}
+
+// clone returns a copy that shares no maps or slices with c.
+func (c Config) clone() Config {
+	out := c
+	out.FrontMatter.Required = append([]string(nil), c.FrontMatter.Required...)
+
+	if c.FrontMatter.Defaults != nil {
+		out.FrontMatter.Defaults = make(map[string]interface{}, len(c.FrontMatter.Defaults))
+		for k, v := range c.FrontMatter.Defaults {
+			out.FrontMatter.Defaults[k] = v
+		}
+	}
+	if c.FrontMatter.Schema != nil {
+		out.FrontMatter.Schema = make(map[string]FieldSchema, len(c.FrontMatter.Schema))
+		for k, v := range c.FrontMatter.Schema {
+			out.FrontMatter.Schema[k] = v
+		}
+	}
+	if c.Slug.Transliterate != nil {
+		out.Slug.Transliterate = make(map[string]string, len(c.Slug.Transliterate))
+		for k, v := range c.Slug.Transliterate {
+			out.Slug.Transliterate[k] = v
+		}
+	}
+	out.Tags.Known = append([]string(nil), c.Tags.Known...)
+	out.Save.AllowedDirs = append([]string(nil), c.Save.AllowedDirs...)
+	if c.Tags.Aliases != nil {
+		out.Tags.Aliases = make(map[string]string, len(c.Tags.Aliases))
+		for k, v := range c.Tags.Aliases {
+			out.Tags.Aliases[k] = v
+		}
+	}
+	if c.Timeouts.Tools != nil {
+		out.Timeouts.Tools = make(map[string]int, len(c.Timeouts.Tools))
+		for k, v := range c.Timeouts.Tools {
+			out.Timeouts.Tools[k] = v
+		}
+	}
+
+	return out
+}
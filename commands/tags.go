@@ -0,0 +1,414 @@
+package commands
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+func init() {
+	registerTool(&Tool{
+		Name:        "bckt_tags",
+		Title:       "Manage Tags",
+		Description: "List or rename tags. Action list shows every tag with the number of posts using it, plus the aliases from the config. Action rename replaces the from tags with to in every post under root_path, drafts and scheduled posts included, merging them when several are given; use preview first to see the affected posts.",
+		Annotations: writeTool,
+		Output:      TagsOutput{},
+		Handler:     handler(HandleBcktTags),
+		Complete: map[string]Completer{
+			"/from": CompleteTags,
+			"/to":   CompleteTags,
+		},
+	})
+}
+
+// tagRegistry knows the canonical spelling of every tag: the ones listed in
+// [tags] known, the ones posts use, and the aliases that map to them.
+type tagRegistry struct {
+	tags      []string          // Canonical tags, most used first
+	posts     map[string]int    // Posts using each canonical tag
+	known     map[string]bool   // Canonical tags listed in [tags] known
+	canonical map[string]string // Lowercased tag or alias to canonical tag
+}
+
+// loadTagRegistry builds the registry from the config and, when root_path
+// is set, the posts under it. Tags differing only in case are one tag,
+// spelled the way the config or most posts spell it.
+func loadTagRegistry(ctx context.Context, cfg Config) (*tagRegistry, error) {
+	r := &tagRegistry{
+		posts:     map[string]int{},
+		known:     map[string]bool{},
+		canonical: map[string]string{},
+	}
+
+	for _, tag := range cfg.Tags.Known {
+		r.add(tag)
+		r.known[r.canonical[tagKey(tag)]] = true
+	}
+
+	aliases := make([]string, 0, len(cfg.Tags.Aliases))
+	for alias := range cfg.Tags.Aliases {
+		aliases = append(aliases, alias)
+	}
+	sort.Strings(aliases)
+	for _, alias := range aliases {
+		target := cfg.Tags.Aliases[alias]
+		r.add(target)
+		if _, ok := r.canonical[tagKey(alias)]; !ok {
+			r.canonical[tagKey(alias)] = r.canonical[tagKey(target)]
+		}
+	}
+
+	var postTags [][]string
+	if cfg.RootPath != "" {
+		var err error
+		postTags, err = cachedPostTags(ctx, cfg)
+		if err != nil {
+			return nil, err
+		}
+		var used []string
+		for _, tags := range postTags {
+			used = append(used, tags...)
+		}
+		for _, tag := range byFrequency(used) {
+			r.add(tag)
+		}
+	}
+
+	for _, tags := range postTags {
+		seen := map[string]bool{}
+		for _, tag := range tags {
+			if canonical, ok := r.lookup(tag); ok && !seen[canonical] {
+				seen[canonical] = true
+				r.posts[canonical]++
+			}
+		}
+	}
+
+	sort.SliceStable(r.tags, func(i, j int) bool {
+		return r.posts[r.tags[i]] > r.posts[r.tags[j]]
+	})
+
+	return r, nil
+}
+
+// postTagsMaxAge is how long the tags of the posts are kept before they
+// are read again, to pick up posts edited outside bckt-mcp.
+const postTagsMaxAge = time.Minute
+
+// postTagsCache keeps the tags of every post, so formatting a post doesn't
+// read all the others. Writes through bckt-mcp drop it with forgetPostTags.
+var postTagsCache struct {
+	sync.Mutex
+	key    string     // root_path and path_pattern the tags were read with
+	tags   [][]string // Tags of each post
+	loaded time.Time
+}
+
+// cachedPostTags returns the tags of each published post, reading the
+// posts only when the cache is empty, stale or for another root_path.
+func cachedPostTags(ctx context.Context, cfg Config) ([][]string, error) {
+	c := &postTagsCache
+	c.Lock()
+	defer c.Unlock()
+
+	key := expandPath(cfg.RootPath) + "\x00" + cfg.PathPattern
+	if c.key == key && time.Since(c.loaded) < postTagsMaxAge {
+		return c.tags, nil
+	}
+
+	posts, err := scanPosts(ctx, cfg)
+	if err != nil {
+		return nil, err
+	}
+	tags := make([][]string, 0, len(posts))
+	for _, post := range posts {
+		tags = append(tags, post.Tags())
+	}
+	c.key, c.tags, c.loaded = key, tags, time.Now()
+	return tags, nil
+}
+
+// forgetPostTags empties the cache of post tags after a post changed.
+func forgetPostTags() {
+	postTagsCache.Lock()
+	postTagsCache.key = ""
+	postTagsCache.tags = nil
+	postTagsCache.Unlock()
+}
+
+// add registers a canonical tag unless it is already known in some spelling.
+func (r *tagRegistry) add(tag string) {
+	tag = strings.TrimSpace(tag)
+	if tag == "" {
+		return
+	}
+	if _, ok := r.canonical[tagKey(tag)]; ok {
+		return
+	}
+	r.canonical[tagKey(tag)] = tag
+	r.tags = append(r.tags, tag)
+}
+
+// lookup returns the canonical form of a tag or alias.
+func (r *tagRegistry) lookup(tag string) (string, bool) {
+	canonical, ok := r.canonical[tagKey(tag)]
+	return canonical, ok
+}
+
+func tagKey(tag string) string {
+	return strings.ToLower(strings.TrimSpace(tag))
+}
+
+// normalizeTags rewrites the tags in front matter to their canonical forms,
+// dropping duplicates. Unknown tags are errors when rejectUnknown is set and
+// warnings otherwise, so new spellings don't slip in unnoticed.
+func normalizeTags(frontMatter map[string]interface{}, r *tagRegistry, rejectUnknown bool) ([]string, *ValidationError) {
+	var tags []string
+	switch v := frontMatter["tags"].(type) {
+	case []interface{}:
+		for _, t := range v {
+			tag, _ := t.(string)
+			tags = append(tags, tag)
+		}
+	case []string:
+		tags = v
+	default:
+		return nil, nil
+	}
+
+	verr := &ValidationError{}
+	var warnings []string
+	normalized := []string{}
+	seen := map[string]bool{}
+	for i, tag := range tags {
+		tag = strings.TrimSpace(tag)
+		if tag == "" {
+			continue
+		}
+
+		canonical, ok := r.lookup(tag)
+		switch {
+		case ok && canonical != tag:
+			warnings = append(warnings, fmt.Sprintf("tag %q rewritten to %q", tag, canonical))
+		case !ok && rejectUnknown:
+			fe := verr.add(pointerJoin("/tags", fmt.Sprint(i)), "unknown tag %q", tag)
+			fe.Hint = "Use an existing tag"
+			if similar := rankMatches(r.tags, tag); len(similar) > 0 {
+				if len(similar) > 5 {
+					similar = similar[:5]
+				}
+				fe.Hint += " such as " + strings.Join(similar, ", ")
+			}
+			fe.Hint += ", add it to [tags] known in the config, or use strategy \"lenient\""
+			continue
+		case !ok:
+			canonical = tag
+			if len(r.tags) > 0 {
+				warnings = append(warnings, fmt.Sprintf("new tag: %s", tag))
+			}
+		}
+
+		if !seen[tagKey(canonical)] {
+			seen[tagKey(canonical)] = true
+			normalized = append(normalized, canonical)
+		}
+	}
+
+	if len(verr.Errors) > 0 {
+		return nil, verr
+	}
+	frontMatter["tags"] = normalized
+	return warnings, nil
+}
+
+func HandleBcktTags(ctx context.Context, id interface{}, args TagsArgs, store *ConfigStore) *Response {
+	if args.Action == "rename" {
+		return renameTags(ctx, id, args, store)
+	}
+
+	cfg := store.Get()
+
+	// Counts are asked for explicitly, so don't show them stale
+	forgetPostTags()
+	registry, err := loadTagRegistry(ctx, cfg)
+	if err != nil {
+		return &Response{
+			JSONRPC: "2.0",
+			ID:      id,
+			Error:   &Error{Code: 1, Message: err.Error()},
+		}
+	}
+
+	output := TagsOutput{Tags: []TagInfo{}, Aliases: cfg.Tags.Aliases}
+	var b strings.Builder
+	if len(registry.tags) == 0 {
+		b.WriteString("No tags found.\n")
+	} else {
+		b.WriteString("Tags (most used first):\n")
+	}
+	for _, tag := range registry.tags {
+		info := TagInfo{Tag: tag, Posts: registry.posts[tag], Known: registry.known[tag]}
+		output.Tags = append(output.Tags, info)
+		fmt.Fprintf(&b, "  %s (%d)", tag, info.Posts)
+		if info.Known {
+			b.WriteString(" known")
+		}
+		b.WriteString("\n")
+	}
+	if len(cfg.Tags.Aliases) > 0 {
+		aliases := make([]string, 0, len(cfg.Tags.Aliases))
+		for alias := range cfg.Tags.Aliases {
+			aliases = append(aliases, alias)
+		}
+		sort.Strings(aliases)
+		b.WriteString("\nAliases:\n")
+		for _, alias := range aliases {
+			fmt.Fprintf(&b, "  %s → %s\n", alias, cfg.Tags.Aliases[alias])
+		}
+	}
+
+	content := []Content{
+		{Type: "text", Text: b.String()},
+	}
+
+	return &Response{
+		JSONRPC: "2.0",
+		ID:      id,
+		Result:  ToolCallResult{Content: content, StructuredContent: output},
+	}
+}
+
+// renameTags replaces the from tags with to in every post, draft and
+// scheduled post, keeping each post's tag order and dropping duplicates
+// created by a merge.
+func renameTags(ctx context.Context, id interface{}, args TagsArgs, store *ConfigStore) *Response {
+	verr := &ValidationError{}
+	from := map[string]bool{}
+	for i, tag := range args.From {
+		if strings.TrimSpace(tag) == "" {
+			verr.add(pointerJoin("/from", fmt.Sprint(i)), "must not be empty").Hint = "Remove the empty tag"
+		}
+		from[tagKey(tag)] = true
+	}
+	if len(args.From) == 0 {
+		verr.add("/from", "is required to rename tags").Hint = "Pass the tags to replace, e.g. [\"golang\", \"go-lang\"]"
+	}
+	to := strings.TrimSpace(args.To)
+	if to == "" {
+		verr.add("/to", "is required to rename tags").Hint = "Pass the tag to use instead, e.g. \"Go\""
+	}
+	if len(verr.Errors) > 0 {
+		return validationFailed(id, verr)
+	}
+
+	cfg := store.Get()
+	posts, err := scanAll(ctx, cfg)
+	if err != nil {
+		return &Response{
+			JSONRPC: "2.0",
+			ID:      id,
+			Error:   &Error{Code: 1, Message: err.Error()},
+		}
+	}
+
+	type change struct {
+		post     *Post
+		markdown string
+	}
+	var changes []change
+	output := TagsOutput{Preview: args.Preview, Changed: []TagChange{}}
+
+	for _, post := range posts {
+		before := post.Tags()
+		after := []string{}
+		seen := map[string]bool{}
+		for _, tag := range before {
+			if from[tagKey(tag)] {
+				tag = to
+			}
+			if !seen[tagKey(tag)] {
+				seen[tagKey(tag)] = true
+				after = append(after, tag)
+			}
+		}
+		if strings.Join(before, "\x00") == strings.Join(after, "\x00") {
+			continue
+		}
+
+		frontMatter := make(map[string]interface{}, len(post.FrontMatter))
+		for k, v := range post.FrontMatter {
+			frontMatter[k] = v
+		}
+		frontMatter["tags"] = after
+
+		markdown, err := rewritePost(post.Raw, frontMatter, post.Body)
+		if err != nil {
+			return &Response{
+				JSONRPC: "2.0",
+				ID:      id,
+				Error:   &Error{Code: 1, Message: fmt.Sprintf("%s: %v", post.Path, err)},
+			}
+		}
+		changes = append(changes, change{post: post, markdown: markdown})
+		output.Changed = append(output.Changed, TagChange{Path: post.Path, Before: before, After: after})
+	}
+
+	var b strings.Builder
+	if args.Preview {
+		b.WriteString("PREVIEW MODE - Not saved\n")
+	}
+	fmt.Fprintf(&b, "Rename %s → %s: %d posts\n", strings.Join(args.From, ", "), to, len(changes))
+	for _, c := range output.Changed {
+		fmt.Fprintf(&b, "  %s\n    [%s] → [%s]\n", c.Path, strings.Join(c.Before, ", "), strings.Join(c.After, ", "))
+	}
+
+	if !args.Preview {
+		if stop := stopIfDone(ctx, id); stop != nil {
+			return stop
+		}
+		for i, c := range changes {
+			if err := writePost(cfg, c.post.Path, []byte(c.markdown)); err != nil {
+				return &Response{
+					JSONRPC: "2.0",
+					ID:      id,
+					Error:   &Error{Code: 1, Message: fmt.Sprintf("Failed to write %s: %v (%d of %d posts already updated)", c.post.Path, err, i, len(changes))},
+				}
+			}
+		}
+
+		if args.Alias {
+			err := store.Update(func(cfg *Config) error {
+				if cfg.Tags.Aliases == nil {
+					cfg.Tags.Aliases = map[string]string{}
+				}
+				for _, tag := range args.From {
+					if tagKey(tag) != tagKey(to) {
+						cfg.Tags.Aliases[tagKey(tag)] = to
+					}
+				}
+				return SaveGlobalConfig(GlobalConfigPath(), cfg)
+			})
+			if err != nil {
+				return &Response{
+					JSONRPC: "2.0",
+					ID:      id,
+					Error:   &Error{Code: 1, Message: fmt.Sprintf("Posts updated, but failed to save aliases: %v", err)},
+				}
+			}
+			fmt.Fprintf(&b, "Recorded %s as aliases of %s\n", strings.Join(args.From, ", "), to)
+		}
+	}
+
+	content := []Content{
+		{Type: "text", Text: b.String()},
+	}
+
+	return &Response{
+		JSONRPC: "2.0",
+		ID:      id,
+		Result:  ToolCallResult{Content: content, StructuredContent: output},
+	}
+}
@@ -0,0 +1,81 @@
+package commands
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestRenameTags(t *testing.T) {
+	posts := map[string]string{
+		"posts/2025/2025-10-07-one/one.md":     "---\ntitle: One\ntags: [golang, mcp] # topics\n---\n\nBody.\n",
+		"posts/2025/2025-10-08-two/two.md":     "---\ntitle: Two\ntags:\n  - Go\n  - go-lang\n---\n\nBody.\n",
+		"posts/2025/2025-10-09-three/three.md": "---\ntitle: Three\ntags: [yaml]\n---\n\nBody.\n",
+		"drafts/four/four.md":                  "---\ntitle: Four\ndraft: true\ntags: [GoLang]\n---\n\nBody.\n",
+	}
+	want := map[string]string{
+		"posts/2025/2025-10-07-one/one.md": "---\ntitle: One\ntags: [Go, mcp] # topics\n---\n\nBody.\n",
+		"posts/2025/2025-10-08-two/two.md": "---\ntitle: Two\ntags:\n  - Go\n---\n\nBody.\n",
+		"drafts/four/four.md":              "---\ntitle: Four\ndraft: true\ntags: [Go]\n---\n\nBody.\n",
+	}
+
+	for _, preview := range []bool{false, true} {
+		name := "rename"
+		if preview {
+			name = "preview"
+		}
+		t.Run(name, func(t *testing.T) {
+			store, root := newTestBlog(t)
+			paths := map[string]string{}
+			for rel, markdown := range posts {
+				paths[rel] = writeTestPost(t, root, rel, markdown)
+			}
+
+			args := map[string]interface{}{"action": "rename", "from": []string{"golang", "go-lang"}, "to": "Go", "alias": true, "preview": preview}
+			output := toolResult(t, callTool(t, store, "bckt_tags", args)).StructuredContent.(TagsOutput)
+
+			changed := map[string]TagChange{}
+			for _, c := range output.Changed {
+				changed[c.Path] = c
+			}
+			if len(changed) != len(want) {
+				t.Errorf("changed %d posts, want %d: %v", len(changed), len(want), output.Changed)
+			}
+			if c := changed[paths["posts/2025/2025-10-08-two/two.md"]]; !reflect.DeepEqual(c.After, []string{"Go"}) {
+				t.Errorf("merged tags = %v, want [Go]", c.After)
+			}
+
+			for rel, path := range paths {
+				expected, ok := want[rel]
+				if preview || !ok {
+					expected = posts[rel]
+				}
+				if got := readTestFile(t, path); got != expected {
+					t.Errorf("%s =\n%s\nwant\n%s", rel, got, expected)
+				}
+			}
+
+			aliases := store.Get().Tags.Aliases
+			if preview {
+				if len(aliases) != 0 {
+					t.Errorf("preview recorded aliases %v", aliases)
+				}
+				return
+			}
+			if !reflect.DeepEqual(aliases, map[string]string{"golang": "Go", "go-lang": "Go"}) {
+				t.Errorf("aliases = %v", aliases)
+			}
+		})
+	}
+}
+
+func TestRenameTagsRequiresFromAndTo(t *testing.T) {
+	store, _ := newTestBlog(t)
+
+	text := toolError(t, callTool(t, store, "bckt_tags", map[string]interface{}{"action": "rename"}))
+	for _, pointer := range []string{"/from", "/to"} {
+		if !strings.Contains(text, `"pointer": "`+pointer+`"`) {
+			t.Errorf("error does not point at %s:\n%s", pointer, text)
+		}
+	}
+}
@@ -0,0 +1,238 @@
+package commands
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"strings"
+)
+
+// ToolHandler runs a tool call with its arguments decoded. Make one with
+// handler, from a function taking the tool's argument struct.
+type ToolHandler interface {
+	args() interface{} // Zero value of the argument struct
+	call(ctx context.Context, id interface{}, arguments json.RawMessage, store *ConfigStore) *Response
+}
+
+// handler wraps a function taking the arguments as an A. The input schema
+// is derived from A, and arguments have been checked against it by the
+// time h is called, so h can rely on required fields and enums.
+func handler[A any](h func(ctx context.Context, id interface{}, args A, store *ConfigStore) *Response) ToolHandler {
+	return typedHandler[A](h)
+}
+
+type typedHandler[A any] func(ctx context.Context, id interface{}, args A, store *ConfigStore) *Response
+
+func (h typedHandler[A]) args() interface{} {
+	var args A
+	return args
+}
+
+func (h typedHandler[A]) call(ctx context.Context, id interface{}, arguments json.RawMessage, store *ConfigStore) *Response {
+	var args A
+	if err := json.Unmarshal(arguments, &args); err != nil {
+		return invalidArgument(id, "", err.Error(), "Send the arguments described by the tool's input schema")
+	}
+	return h(ctx, id, args, store)
+}
+
+// Tool describes one MCP tool. Output is the zero value of the result type;
+// its schema, and the input schema of the handler's arguments, are derived
+// with schemaFor. Complete maps JSON pointers of argument fields to their
+// completers.
+type Tool struct {
+	Name        string
+	Title       string
+	Description string
+	Annotations *ToolAnnotations
+	Output      interface{}
+	Handler     ToolHandler
+	Complete    map[string]Completer
+}
+
+// inputSchema is the schema of the tool's arguments.
+func (t *Tool) inputSchema() map[string]interface{} {
+	return schemaFor(t.Handler.args())
+}
+
+// ToolAnnotations are hints about a tool's behavior, e.g. for clients that
+// auto-approve read-only calls. Unset hints take the spec defaults.
+type ToolAnnotations struct {
+	ReadOnlyHint    *bool `json:"readOnlyHint,omitempty"`
+	DestructiveHint *bool `json:"destructiveHint,omitempty"`
+	IdempotentHint  *bool `json:"idempotentHint,omitempty"`
+	OpenWorldHint   *bool `json:"openWorldHint,omitempty"`
+}
+
+// ToolDefinition is a tool as listed by tools/list.
+type ToolDefinition struct {
+	Name         string           `json:"name"`
+	Title        string           `json:"title,omitempty"`
+	Description  string           `json:"description"`
+	InputSchema  interface{}      `json:"inputSchema"`
+	OutputSchema interface{}      `json:"outputSchema,omitempty"`
+	Annotations  *ToolAnnotations `json:"annotations,omitempty"`
+}
+
+// Annotations shared by the tools. No tool reaches outside the local blog
+// and config, so none are open-world.
+var (
+	readOnlyTool = &ToolAnnotations{
+		ReadOnlyHint:  boolPtr(true),
+		OpenWorldHint: boolPtr(false),
+	}
+	idempotentWriteTool = &ToolAnnotations{
+		ReadOnlyHint:    boolPtr(false),
+		DestructiveHint: boolPtr(true),
+		IdempotentHint:  boolPtr(true),
+		OpenWorldHint:   boolPtr(false),
+	}
+	writeTool = &ToolAnnotations{
+		ReadOnlyHint:    boolPtr(false),
+		DestructiveHint: boolPtr(true),
+		IdempotentHint:  boolPtr(false),
+		OpenWorldHint:   boolPtr(false),
+	}
+)
+
+func boolPtr(b bool) *bool {
+	return &b
+}
+
+// tools holds the registered tools in registration order.
+var tools []*Tool
+
+// registerTool adds a tool to the registry. Each tool registers itself from
+// an init function next to its handler.
+func registerTool(tool *Tool) {
+	for _, t := range tools {
+		if t.Name == tool.Name {
+			panic("duplicate tool: " + tool.Name)
+		}
+	}
+	tools = append(tools, tool)
+}
+
+func findTool(name string) *Tool {
+	for _, t := range tools {
+		if t.Name == name {
+			return t
+		}
+	}
+	return nil
+}
+
+// ToolDefinitions lists every registered tool with its schemas.
+func ToolDefinitions() []ToolDefinition {
+	definitions := make([]ToolDefinition, 0, len(tools))
+	for _, t := range tools {
+		definition := ToolDefinition{
+			Name:        t.Name,
+			Title:       t.Title,
+			Description: t.Description,
+			InputSchema: t.inputSchema(),
+			Annotations: t.Annotations,
+		}
+		if t.Output != nil {
+			definition.OutputSchema = schemaFor(t.Output)
+		}
+		definitions = append(definitions, definition)
+	}
+	return definitions
+}
+
+// CallTool validates the arguments of a tool call against the tool's input
+// schema, decodes them and runs its handler.
+func CallTool(ctx context.Context, id interface{}, params ToolCallParams, store *ConfigStore) *Response {
+	tool := findTool(params.Name)
+	if tool == nil {
+		return &Response{
+			JSONRPC: "2.0",
+			ID:      id,
+			Error:   &Error{Code: -32601, Message: "Unknown tool"},
+		}
+	}
+
+	arguments := json.RawMessage("{}")
+	if params.Arguments != nil {
+		arguments = *params.Arguments
+	}
+
+	var args interface{}
+	if err := json.Unmarshal(arguments, &args); err != nil {
+		return invalidArgument(id, "", fmt.Sprintf("arguments are not valid JSON: %v", err), "Send the arguments as a JSON object")
+	}
+	if verr := validateArguments(args, tool.inputSchema()); verr != nil {
+		return validationFailed(id, verr)
+	}
+
+	return tool.Handler.call(ctx, id, arguments, store)
+}
+
+// ReadOnlyTool reports whether a tool never changes files. The server gives
+// up on read-only tools that run past their timeout, but waits for the
+// others, which stop with stopIfDone before they write anything.
+func ReadOnlyTool(name string) bool {
+	tool := findTool(name)
+	return tool != nil && tool.Annotations != nil && tool.Annotations.ReadOnlyHint != nil && *tool.Annotations.ReadOnlyHint
+}
+
+// stopIfDone returns an error response when ctx has been cancelled or has
+// timed out. Write tools call it right before they change anything; once
+// they start writing they finish and report what they did.
+func stopIfDone(ctx context.Context, id interface{}) *Response {
+	err := ctx.Err()
+	if err == nil {
+		return nil
+	}
+	message := "Cancelled, nothing was changed"
+	if err == context.DeadlineExceeded {
+		message = "Timed out, nothing was changed. Call the tool again, or raise its limit in [timeouts]"
+	}
+	return &Response{
+		JSONRPC: "2.0",
+		ID:      id,
+		Error:   &Error{Code: 1, Message: message},
+	}
+}
+
+// validationFailed reports invalid arguments as a tool result with isError
+// set, rather than a protocol error, so the model can read the problems,
+// fix them and call the tool again.
+func validationFailed(id interface{}, verr *ValidationError) *Response {
+	return problemsFound(id, "Invalid arguments, nothing was done. Fix these and call the tool again:", verr)
+}
+
+// problemsFound reports validation problems as an isError tool result,
+// introduced by intro. The problems go in the text only: structuredContent
+// has to match the tool's outputSchema, which describes its success output.
+func problemsFound(id interface{}, intro string, verr *ValidationError) *Response {
+	var b strings.Builder
+	b.WriteString(intro + "\n")
+	for _, fe := range verr.Errors {
+		fmt.Fprintf(&b, "- %s: %s", displayPointer(fe.Pointer), fe.Message)
+		if fe.Hint != "" {
+			fmt.Fprintf(&b, " (%s)", fe.Hint)
+		}
+		b.WriteString("\n")
+	}
+
+	structured, _ := json.MarshalIndent(verr, "", "  ")
+
+	return &Response{
+		JSONRPC: "2.0",
+		ID:      id,
+		Result: ToolCallResult{
+			Content: []Content{
+				{Type: "text", Text: b.String()},
+				{Type: "text", Text: string(structured)},
+			},
+			IsError: true,
+		},
+	}
+}
+
+// invalidArgument reports a single invalid argument, see validationFailed.
+func invalidArgument(id interface{}, pointer, message, hint string) *Response {
+	return validationFailed(id, &ValidationError{Errors: []FieldError{{Pointer: pointer, Message: message, Hint: hint}}})
+}
@@ -0,0 +1,146 @@
+package commands
+
+import (
+	"context"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// newTestBlog returns a store configured for an empty blog in a temporary
+// directory, with HOME and backups pointing there too.
+func newTestBlog(t *testing.T) (*ConfigStore, string) {
+	t.Helper()
+	dir := t.TempDir()
+	t.Setenv("HOME", dir)
+
+	cfg := GetDefaultConfig()
+	cfg.RootPath = filepath.Join(dir, "blog")
+	cfg.Timezone = "UTC"
+	cfg.Backup.Dir = filepath.Join(dir, "backups")
+	if err := os.MkdirAll(cfg.RootPath, 0755); err != nil {
+		t.Fatal(err)
+	}
+	forgetPostTags()
+	return NewConfigStore(&cfg), cfg.RootPath
+}
+
+// writeTestPost writes a post below root and returns its full path.
+func writeTestPost(t *testing.T, root, rel, markdown string) string {
+	t.Helper()
+	path := filepath.Join(root, filepath.FromSlash(rel))
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(path, []byte(markdown), 0644); err != nil {
+		t.Fatal(err)
+	}
+	return path
+}
+
+func readTestFile(t *testing.T, path string) string {
+	t.Helper()
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return string(data)
+}
+
+// callTool runs a tool the way a tools/call request does, with args
+// encoded as JSON.
+func callTool(t *testing.T, store *ConfigStore, name string, args interface{}) *Response {
+	t.Helper()
+	data, err := json.Marshal(args)
+	if err != nil {
+		t.Fatal(err)
+	}
+	raw := json.RawMessage(data)
+	return CallTool(context.Background(), 1, ToolCallParams{Name: name, Arguments: &raw}, store)
+}
+
+// toolResult returns the result of a call, failing the test unless the
+// call succeeded.
+func toolResult(t *testing.T, response *Response) ToolCallResult {
+	t.Helper()
+	if response.Error != nil {
+		t.Fatalf("tool call failed: %s", response.Error.Message)
+	}
+	result, ok := response.Result.(ToolCallResult)
+	if !ok {
+		t.Fatalf("unexpected result %#v", response.Result)
+	}
+	if result.IsError {
+		t.Fatalf("tool call failed: %s", resultText(result))
+	}
+	return result
+}
+
+// toolError returns the text of an isError result, failing the test when
+// the call succeeded or failed some other way.
+func toolError(t *testing.T, response *Response) string {
+	t.Helper()
+	if response.Error != nil {
+		t.Fatalf("tool call failed with a protocol error: %s", response.Error.Message)
+	}
+	result, ok := response.Result.(ToolCallResult)
+	if !ok || !result.IsError {
+		t.Fatalf("tool call succeeded, want an isError result: %#v", response.Result)
+	}
+	return resultText(result)
+}
+
+func resultText(result ToolCallResult) string {
+	var texts []string
+	for _, c := range result.Content {
+		texts = append(texts, c.Text)
+	}
+	return strings.Join(texts, "\n")
+}
+
+func TestErrorResultsLeaveStructuredContentOut(t *testing.T) {
+	store, _ := newTestBlog(t)
+
+	// bckt_save declares an outputSchema that errors would not match
+	response := callTool(t, store, "bckt_save", map[string]interface{}{"markdown": ""})
+	text := toolError(t, response)
+	if result := response.Result.(ToolCallResult); result.StructuredContent != nil {
+		t.Errorf("isError result has structuredContent %#v", result.StructuredContent)
+	}
+	if !strings.Contains(text, `"pointer": "/markdown"`) {
+		t.Errorf("error text lacks the JSON problems:\n%s", text)
+	}
+}
+
+func TestUnknownArguments(t *testing.T) {
+	store, _ := newTestBlog(t)
+
+	tests := []struct {
+		tool string
+		args map[string]interface{}
+		want string // Expected in the error text
+	}{
+		{"bckt_list_posts", map[string]interface{}{"limt": 5}, `Did you mean \"limit\"?`},
+		{"bckt_save", map[string]interface{}{"markdown": "x", "on_conflicts": "error"}, `Did you mean \"on_conflict\"?`},
+		{"bckt_list_posts", map[string]interface{}{"colour": "red"}, "Remove it, or use one of:"},
+		{"bckt_config_view", map[string]interface{}{"verbose": true}, "takes no arguments"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.tool+"/"+tt.want, func(t *testing.T) {
+			text := toolError(t, callTool(t, store, tt.tool, tt.args))
+			if !strings.Contains(text, "unknown argument") || !strings.Contains(text, tt.want) {
+				t.Errorf("error does not report the unknown argument with %q:\n%s", tt.want, text)
+			}
+		})
+	}
+
+	// The published schemas say so too
+	for _, tool := range tools {
+		if tool.inputSchema()["additionalProperties"] != false {
+			t.Errorf("%s input schema allows additional properties", tool.Name)
+		}
+	}
+}
@@ -28,7 +28,9 @@ type ToolCallParams struct {
 }
 
 type ToolCallResult struct {
-	Content []Content `json:"content"`
+	Content           []Content   `json:"content"`
+	StructuredContent interface{} `json:"structuredContent,omitempty"` // Matches the tool's outputSchema
+	IsError           bool        `json:"isError,omitempty"`
 }
 
 type Content struct {
@@ -37,12 +39,34 @@ type Content struct {
 	JSON interface{} `json:"json,omitempty"`
 }
 
-// Tool input/output types
+// Tool input/output types. Input and output schemas are derived from these
+// with schemaFor, so the json, description and enum tags are part of the
+// tools' public interface.
 type FormatInput struct {
-	Raw      string                 `json:"raw"`
-	Meta     map[string]interface{} `json:"meta"`
-	Config   string                 `json:"config,omitempty"`
-	Strategy string                 `json:"strategy,omitempty"`
+	Raw      string   `json:"raw" description:"Raw markdown content"`
+	Meta     PostMeta `json:"meta" description:"Metadata for front matter"`
+	Config   string   `json:"config,omitempty" description:"Optional TOML configuration"`
+	Strategy string   `json:"strategy,omitempty" enum:"strict,lenient" description:"Validation strategy"`
+}
+
+// PostMeta is the front matter passed to bckt and bckt_preview.
+type PostMeta map[string]interface{}
+
+// JSONSchema lists the well-known front matter fields; others are allowed.
+func (PostMeta) JSONSchema() map[string]interface{} {
+	return map[string]interface{}{
+		"type": "object",
+		"properties": map[string]interface{}{
+			"title":    map[string]interface{}{"type": "string"},
+			"slug":     map[string]interface{}{"type": "string"},
+			"date":     map[string]interface{}{"type": "string", "description": "Publication date (default: now), e.g. 2025-10-07 09:30, Oct 7, 2025 or tomorrow at 9am"},
+			"tags":     map[string]interface{}{"type": "array", "items": map[string]interface{}{"type": "string"}},
+			"abstract": map[string]interface{}{"type": "string"},
+			"lang":     map[string]interface{}{"type": "string"},
+			"draft":    map[string]interface{}{"type": "boolean", "description": "Save as a draft under drafts_pattern"},
+		},
+		"required": []string{"title"},
+	}
 }
 
 type FormatOutput struct {
@@ -51,16 +75,278 @@ type FormatOutput struct {
 	Warnings []string `json:"warnings"`
 }
 
+type SaveArgs struct {
+	Markdown   string `json:"markdown" description:"The complete formatted markdown with front matter"`
+	Path       string `json:"path,omitempty" description:"Where to save. Leave out to compute it from the front matter and path_pattern. Must be under root_path, or for absolute paths under a [save] allowed_dirs directory"`
+	RootPath   string `json:"root_path,omitempty" description:"Root directory for blog posts (required on first save, then saved to config)"`
+	OnConflict string `json:"on_conflict,omitempty" enum:"error,overwrite,suffix,diff" description:"What to do when the file already exists: error (default) refuses, overwrite replaces it, suffix saves under slug-2, slug-3…, diff returns a unified diff without saving"`
+	OnMismatch string `json:"on_mismatch,omitempty" enum:"error,warn" description:"What to do when path differs from the path the front matter gives: error (default) refuses, warn saves at path with a warning"`
+	Config     string `json:"config,omitempty" description:"The inline TOML config passed to bckt, if any, so the path is computed with the same patterns"`
+}
+
+type SaveOutput struct {
+	Path     string   `json:"path" description:"Where the post was saved (or would be, for diff)"`
+	Status   string   `json:"status" enum:"saved,overwritten,suffixed,unchanged,diff"`
+	Existing string   `json:"existing,omitempty" description:"The existing file, for suffixed and diff"`
+	Diff     string   `json:"diff,omitempty" description:"Unified diff against the existing file, for diff"`
+	Warnings []string `json:"warnings,omitempty"`
+}
+
+type ConfigArgs struct {
+	RootPath         string `json:"root_path,omitempty" description:"Root directory for blog posts"`
+	Timezone         string `json:"timezone,omitempty" description:"Timezone for dates (e.g., 'America/New_York', 'Europe/London', 'UTC')"`
+	PathPattern      string `json:"path_pattern,omitempty" description:"Path pattern with placeholders {yyyy} {yy} {MM} {DD} {HH} {mm} {week} {slug} {lang} {first_tag} {section} {id} {meta.FIELD}, and optional segments: {lang!=en:/{lang}} writes /{lang} only when lang isn't en ({name:...} when set, {name=value:...} when equal)"`
+	DraftsPattern    string `json:"drafts_pattern,omitempty" description:"Path pattern for drafts, same placeholders as path_pattern"`
+	ScheduledPattern string `json:"scheduled_pattern,omitempty" description:"Path pattern for posts dated in the future, same placeholders as path_pattern"`
+	WrapAt           int    `json:"wrap_at,omitempty" description:"Line width for text wrapping"`
+}
+
+type ConfigOutput struct {
+	ConfigPath       string   `json:"configPath"`
+	RootPath         string   `json:"rootPath"`
+	Timezone         string   `json:"timezone"`
+	PathPattern      string   `json:"pathPattern"`
+	DraftsPattern    string   `json:"draftsPattern"`
+	ScheduledPattern string   `json:"scheduledPattern"`
+	WrapAt           int      `json:"wrapAt"`
+	Updated          []string `json:"updated,omitempty" description:"Settings changed by this call"`
+	FrontMatter      struct {
+		Required []string               `json:"required"`
+		Defaults map[string]interface{} `json:"defaults"`
+		Schema   map[string]FieldSchema `json:"schema,omitempty"`
+	} `json:"frontMatter"`
+}
+
+type SetupArgs struct {
+	RootPath    string `json:"root_path" description:"Root directory for blog posts"`
+	Timezone    string `json:"timezone" description:"Timezone for dates"`
+	PathPattern string `json:"path_pattern,omitempty" description:"Path pattern (optional, uses default if not provided), same placeholders as bckt_config path_pattern"`
+	WrapAt      int    `json:"wrap_at,omitempty" description:"Line width for text wrapping (optional, uses default if not provided)"`
+	Confirm     bool   `json:"confirm,omitempty" description:"Set to true to save the configuration"`
+}
+
+type SetupOutput struct {
+	Saved       bool   `json:"saved" description:"False when only previewing"`
+	ConfigPath  string `json:"configPath"`
+	RootPath    string `json:"rootPath"`
+	Timezone    string `json:"timezone"`
+	PathPattern string `json:"pathPattern"`
+	WrapAt      int    `json:"wrapAt"`
+}
+
+type ListPostsArgs struct {
+	From   string `json:"from,omitempty" description:"Only posts on or after this day, e.g. 2025-03-01 or \"last month\""`
+	To     string `json:"to,omitempty" description:"Only posts on or before this day, e.g. 2025-03-31 or today"`
+	Tag    string `json:"tag,omitempty" description:"Only posts with this tag (case-insensitive)"`
+	Lang   string `json:"lang,omitempty" description:"Only posts with this language code"`
+	Title  string `json:"title,omitempty" description:"Only posts whose title contains this text (case-insensitive)"`
+	Sort   string `json:"sort,omitempty" enum:"date_desc,date_asc,title" description:"Sort order (default: date_desc)"`
+	Limit  int    `json:"limit,omitempty" description:"Maximum number of posts to return (default: 20, max: 100)"`
+	Cursor string `json:"cursor,omitempty" description:"Cursor from a previous call to continue paging"`
+}
+
+type UpdateArgs struct {
+	Path     string                 `json:"path,omitempty" description:"Path of the post, absolute or relative to root_path"`
+	Slug     string                 `json:"slug,omitempty" description:"Slug of the post (used when path is not given)"`
+	Meta     map[string]interface{} `json:"meta,omitempty" description:"Front matter fields to change. Set a field to null to remove it"`
+	Body     *string                `json:"body,omitempty" description:"New markdown body replacing the existing one"`
+	Strategy string                 `json:"strategy,omitempty" enum:"strict,lenient" description:"Validation strategy (default: lenient)"`
+	Preview  bool                   `json:"preview,omitempty" description:"Show the updated post without saving"`
+}
+
+type UpdateOutput struct {
+	Path     string   `json:"path"`
+	Preview  bool     `json:"preview"`
+	Changed  []string `json:"changed" description:"Changed front matter keys, and body"`
+	Warnings []string `json:"warnings"`
+	Markdown string   `json:"markdown"`
+}
+
+type RenameArgs struct {
+	Path    string `json:"path,omitempty" description:"Path of the post, absolute or relative to root_path"`
+	Slug    string `json:"slug,omitempty" description:"Current slug of the post (used when path is not given)"`
+	NewSlug string `json:"new_slug" description:"The new slug"`
+	Preview bool   `json:"preview,omitempty" description:"Show what would be moved without changing anything"`
+}
+
+type RenameOutput struct {
+	Preview bool     `json:"preview"`
+	OldSlug string   `json:"oldSlug"`
+	NewSlug string   `json:"newSlug"`
+	From    string   `json:"from" description:"The moved file or post directory"`
+	To      string   `json:"to"`
+	Path    string   `json:"path" description:"The post's new path"`
+	Aliases []string `json:"aliases"`
+}
+
+type PublishArgs struct {
+	Path    string `json:"path,omitempty" description:"Path of the draft, absolute or relative to root_path"`
+	Slug    string `json:"slug,omitempty" description:"Slug of the draft (used when path is not given)"`
+	Date    string `json:"date,omitempty" description:"Publish date (default: now), e.g. 2025-10-07 09:30 or tomorrow at 9am"`
+	Preview bool   `json:"preview,omitempty" description:"Show where the draft would go without moving it"`
+}
+
+type PublishOutput struct {
+	Preview   bool   `json:"preview"`
+	Scheduled bool   `json:"scheduled" description:"The date is in the future, so the post went to the scheduled queue"`
+	From      string `json:"from" description:"The moved draft file or directory"`
+	To        string `json:"to"`
+	Path      string `json:"path" description:"The published post's path"`
+	Date      string `json:"date"`
+	Markdown  string `json:"markdown"`
+}
+
+type PublishDueArgs struct {
+	Preview bool `json:"preview,omitempty" description:"List the posts that are due without moving them"`
+}
+
+type PublishDueOutput struct {
+	Preview   bool            `json:"preview"`
+	Published []ScheduledPost `json:"published" description:"Posts whose date has passed, moved into place unless previewing"`
+	Pending   []ScheduledPost `json:"pending" description:"Posts still waiting for their date, soonest first"`
+	Failed    []ScheduledPost `json:"failed,omitempty" description:"Posts that could not be published"`
+}
+
+type ScheduledPost struct {
+	Title string `json:"title"`
+	Date  string `json:"date"`
+	From  string `json:"from" description:"Path in the scheduled queue"`
+	To    string `json:"to,omitempty" description:"Published path"`
+	Error string `json:"error,omitempty"`
+}
+
+type BackupsArgs struct {
+	Action string `json:"action,omitempty" enum:"list,restore" description:"list (default) or restore"`
+	Path   string `json:"path,omitempty" description:"Only list backups of this file (absolute or relative to root_path)"`
+	ID     string `json:"id,omitempty" description:"Backup id to restore (from the list output)"`
+}
+
+type BackupsOutput struct {
+	Dir      string   `json:"dir" description:"The backup directory"`
+	Backups  []Backup `json:"backups,omitempty" description:"For list, newest first"`
+	Restored string   `json:"restored,omitempty" description:"For restore, the file put back"`
+}
+
+type TagsArgs struct {
+	Action  string   `json:"action,omitempty" enum:"list,rename" description:"list (default) or rename"`
+	From    []string `json:"from,omitempty" description:"For rename, the tags to replace (case-insensitive). Several tags are merged into to"`
+	To      string   `json:"to,omitempty" description:"For rename, the tag to use instead"`
+	Alias   bool     `json:"alias,omitempty" description:"For rename, also record the old tags as aliases of to in the config"`
+	Preview bool     `json:"preview,omitempty" description:"For rename, show the affected posts without changing anything"`
+}
+
+type TagsOutput struct {
+	Tags    []TagInfo         `json:"tags,omitempty" description:"For list, most used first"`
+	Aliases map[string]string `json:"aliases,omitempty" description:"For list, alias to canonical tag"`
+	Preview bool              `json:"preview"`
+	Changed []TagChange       `json:"changed,omitempty" description:"For rename, the posts whose tags change"`
+}
+
+type TagInfo struct {
+	Tag   string `json:"tag"`
+	Posts int    `json:"posts"`
+	Known bool   `json:"known" description:"Listed in [tags] known"`
+}
+
+type TagChange struct {
+	Path   string   `json:"path"`
+	Before []string `json:"before"`
+	After  []string `json:"after"`
+}
+
 // Configuration types
 type Config struct {
-	RootPath    string `toml:"root_path"`
-	Timezone    string `toml:"timezone"`
-	PathPattern string `toml:"path_pattern"`
-	FrontMatter struct {
+	RootPath         string `toml:"root_path"`
+	Timezone         string `toml:"timezone"`
+	PathPattern      string `toml:"path_pattern"`
+	DraftsPattern    string `toml:"drafts_pattern"`
+	ScheduledPattern string `toml:"scheduled_pattern"`
+	FrontMatter      struct {
 		Required []string               `toml:"required"`
 		Defaults map[string]interface{} `toml:"defaults"`
+		Schema   map[string]FieldSchema `toml:"schema,omitempty"`
 	} `toml:"front_matter"`
 	MarkdownRule struct {
 		WrapAt int `toml:"wrap_at"`
 	} `toml:"markdown_rules"`
+	Slug struct {
+		Transliterate map[string]string `toml:"transliterate"`
+		Fallback      string            `toml:"fallback"`
+	} `toml:"slug"`
+	Backup struct {
+		Dir  string `toml:"dir"`
+		Keep int    `toml:"keep"`
+	} `toml:"backup"`
+	Timeouts struct {
+		Default int            `toml:"default"`         // Seconds, negative disables the limit
+		Tools   map[string]int `toml:"tools,omitempty"` // Per-tool overrides
+	} `toml:"timeouts"`
+	Tags struct {
+		Known         []string          `toml:"known,omitempty"`          // Canonical spellings
+		Aliases       map[string]string `toml:"aliases,omitempty"`        // Alias to canonical tag
+		RejectUnknown bool              `toml:"reject_unknown,omitempty"` // Reject new tags in strict mode
+	} `toml:"tags"`
+	Save struct {
+		AllowedDirs []string `toml:"allowed_dirs,omitempty"` // Directories outside root_path bckt_save may write to
+	} `toml:"save"`
+}
+
+// Resource types
+type Resource struct {
+	URI         string                 `json:"uri"`
+	Name        string                 `json:"name"`
+	Title       string                 `json:"title,omitempty"`
+	Description string                 `json:"description,omitempty"`
+	MimeType    string                 `json:"mimeType,omitempty"`
+	Meta        map[string]interface{} `json:"_meta,omitempty"`
+}
+
+type ResourcesListResult struct {
+	Resources []Resource `json:"resources"`
+}
+
+type ResourceReadParams struct {
+	URI string `json:"uri"`
+}
+
+type ResourceContents struct {
+	URI      string `json:"uri"`
+	MimeType string `json:"mimeType,omitempty"`
+	Text     string `json:"text"`
+}
+
+type ResourceReadResult struct {
+	Contents []ResourceContents `json:"contents"`
+}
+
+// Completion types
+type CompleteParams struct {
+	Ref struct {
+		Type string `json:"type"` // ref/prompt, ref/resource or ref/tool
+		Name string `json:"name,omitempty"`
+		URI  string `json:"uri,omitempty"`
+	} `json:"ref"`
+	Argument struct {
+		Name  string `json:"name"`
+		Value string `json:"value"`
+	} `json:"argument"`
+}
+
+type Completion struct {
+	Values  []string `json:"values"`
+	Total   int      `json:"total,omitempty"`
+	HasMore bool     `json:"hasMore"`
+}
+
+type CompleteResult struct {
+	Completion Completion `json:"completion"`
+}
+
+// FieldSchema describes one front matter field in [front_matter.schema.NAME].
+type FieldSchema struct {
+	Type      string   `toml:"type" json:"type"` // string, list, date, bool or int
+	Enum      []string `toml:"enum,omitempty" json:"enum,omitempty"`
+	Pattern   string   `toml:"pattern,omitempty" json:"pattern,omitempty"`
+	MaxLength int      `toml:"max_length,omitempty" json:"maxLength,omitempty"`
+	Auto      *bool    `toml:"auto,omitempty" json:"auto,omitempty"` // Generated when missing (slug, date)
 }
@@ -0,0 +1,196 @@
+package commands
+
+import (
+	"context"
+	"fmt"
+	"reflect"
+	"sort"
+	"strings"
+)
+
+func init() {
+	registerTool(&Tool{
+		Name:        "bckt_update",
+		Title:       "Update Post",
+		Description: "Edit an existing post in place. Finds the post by path or slug, applies a partial meta patch (null removes a key) and/or replaces the body, then re-validates, re-wraps and saves it. The date and slug are preserved; keys not in the patch are left untouched.",
+		Annotations: idempotentWriteTool,
+		Output:      UpdateOutput{},
+		Handler:     handler(HandleBcktUpdate),
+		Complete: map[string]Completer{
+			"/path":      CompletePaths,
+			"/slug":      CompleteSlugs,
+			"/meta/tags": CompleteTags,
+			"/meta/lang": CompleteLangs,
+		},
+	})
+}
+
+func HandleBcktUpdate(ctx context.Context, id interface{}, args UpdateArgs, store *ConfigStore) *Response {
+	if args.Path == "" && args.Slug == "" {
+		return invalidArgument(id, "/path", "path or slug is required", "Pass the post's path, or its slug as slug")
+	}
+	if len(args.Meta) == 0 && args.Body == nil {
+		return invalidArgument(id, "", "nothing to update", "Pass meta with the fields to change and/or body with the new text")
+	}
+
+	cfg := store.Get()
+
+	post, err := findPost(ctx, cfg, args.Path, args.Slug)
+	if err != nil {
+		return &Response{
+			JSONRPC: "2.0",
+			ID:      id,
+			Error:   &Error{Code: 1, Message: err.Error()},
+		}
+	}
+
+	frontMatter, changed, verr := patchFrontMatter(post.FrontMatter, args.Meta)
+	if verr != nil {
+		return validationFailed(id, verr.under("/meta"))
+	}
+
+	// Existing posts may carry keys we never generate, so only reject
+	// unknown fields when explicitly asked to
+	strict := args.Strategy == "strict"
+	warnings, err := validateFrontMatter(frontMatter, cfg, strict)
+	if verr, ok := err.(*ValidationError); ok {
+		return validationFailed(id, verr.under("/meta"))
+	}
+
+	// Only tags in the patch are normalized, the rest of the post is left alone
+	if _, ok := args.Meta["tags"]; ok && frontMatter["tags"] != nil {
+		registry, err := loadTagRegistry(ctx, cfg)
+		if err != nil {
+			return &Response{
+				JSONRPC: "2.0",
+				ID:      id,
+				Error:   &Error{Code: 1, Message: err.Error()},
+			}
+		}
+		tagWarnings, verr := normalizeTags(frontMatter, registry, strict && cfg.Tags.RejectUnknown)
+		if verr != nil {
+			return validationFailed(id, verr.under("/meta"))
+		}
+		warnings = append(warnings, tagWarnings...)
+	}
+
+	if abstract, ok := frontMatter["abstract"].(string); ok && abstract != "" {
+		frontMatter["abstract"] = wrapText(abstract, cfg.MarkdownRule.WrapAt)
+	}
+
+	body := post.Body
+	if args.Body != nil {
+		body = *args.Body
+		changed = append(changed, "body")
+	}
+	body = wrapText(body, cfg.MarkdownRule.WrapAt)
+
+	markdown, err := rewritePost(post.Raw, frontMatter, body)
+	if err != nil {
+		return &Response{
+			JSONRPC: "2.0",
+			ID:      id,
+			Error:   &Error{Code: 1, Message: err.Error()},
+		}
+	}
+
+	content := []Content{}
+	if len(warnings) > 0 {
+		warningText := "Warnings:\n- " + strings.Join(warnings, "\n- ")
+		content = append(content, Content{Type: "text", Text: warningText})
+	}
+
+	if args.Preview {
+		content = append(content, Content{Type: "text", Text: "PREVIEW MODE - Not saved"})
+	} else {
+		if stop := stopIfDone(ctx, id); stop != nil {
+			return stop
+		}
+		if err := writePost(cfg, post.Path, []byte(markdown)); err != nil {
+			return &Response{
+				JSONRPC: "2.0",
+				ID:      id,
+				Error:   &Error{Code: 1, Message: fmt.Sprintf("Failed to write file: %v", err)},
+			}
+		}
+		content = append(content, Content{Type: "text", Text: fmt.Sprintf("✓ Updated: %s", post.Path)})
+	}
+
+	output := UpdateOutput{
+		Path:     post.Path,
+		Preview:  args.Preview,
+		Changed:  changed,
+		Warnings: warnings,
+		Markdown: markdown,
+	}
+	if output.Changed == nil {
+		output.Changed = []string{}
+	}
+	if output.Warnings == nil {
+		output.Warnings = []string{}
+	}
+
+	if len(changed) == 0 {
+		changed = []string{"nothing"}
+	}
+	content = append(content, Content{Type: "text", Text: "Changed: " + strings.Join(changed, ", ")})
+	content = append(content, Content{Type: "text", Text: markdown})
+
+	return &Response{
+		JSONRPC: "2.0",
+		ID:      id,
+		Result:  ToolCallResult{Content: content, StructuredContent: output},
+	}
+}
+
+// patchFrontMatter applies a partial update to a copy of the front matter.
+// A null value removes the key. The date and slug are fixed once published.
+func patchFrontMatter(original, patch map[string]interface{}) (map[string]interface{}, []string, *ValidationError) {
+	frontMatter := make(map[string]interface{}, len(original))
+	for k, v := range original {
+		frontMatter[k] = v
+	}
+
+	keys := make([]string, 0, len(patch))
+	for k := range patch {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+
+	verr := &ValidationError{}
+	var changed []string
+	for _, k := range keys {
+		v := patch[k]
+		if k == "date" || k == "slug" {
+			if current, ok := original[k]; ok && fmt.Sprint(current) == fmt.Sprint(v) {
+				continue
+			}
+			fe := verr.add(pointerJoin("", k), "%s cannot be changed with bckt_update", k)
+			fe.Hint = fmt.Sprintf("Leave %s out of meta", k)
+			if k == "slug" {
+				fe.Hint = "Leave slug out of meta and use bckt_rename to change it"
+			}
+			continue
+		}
+
+		if v == nil {
+			if _, ok := frontMatter[k]; ok {
+				delete(frontMatter, k)
+				changed = append(changed, k)
+			}
+			continue
+		}
+
+		if current, ok := frontMatter[k]; ok && reflect.DeepEqual(current, v) {
+			continue
+		}
+		frontMatter[k] = v
+		changed = append(changed, k)
+	}
+	if len(verr.Errors) > 0 {
+		return nil, nil, verr
+	}
+	sort.Strings(changed)
+
+	return frontMatter, changed, nil
+}
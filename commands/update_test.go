@@ -0,0 +1,87 @@
+package commands
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestUpdate(t *testing.T) {
+	rel := "posts/2025/2025-10-07-post/post.md"
+	original := strings.Join([]string{
+		"---",
+		"# Written by hand",
+		"title: 'A Post' # shown in lists",
+		"slug: post",
+		"date: 2025-10-07 09:30:00 +0000",
+		"tags: [go, mcp]",
+		"abstract: \"\"",
+		"lang: en",
+		"series: intro",
+		"---",
+		"",
+		"Body.",
+		"",
+	}, "\n")
+
+	tests := []struct {
+		name    string
+		meta    map[string]interface{}
+		body    string
+		want    string // Expected file after the update
+		changed []string
+	}{
+		{
+			name:    "title keeps quoting and comments",
+			meta:    map[string]interface{}{"title": "A Better Post"},
+			want:    strings.Replace(original, "'A Post'", "'A Better Post'", 1),
+			changed: []string{"title"},
+		},
+		{
+			name:    "flow list stays a flow list",
+			meta:    map[string]interface{}{"tags": []string{"go", "yaml"}},
+			want:    strings.Replace(original, "[go, mcp]", "[go, yaml]", 1),
+			changed: []string{"tags"},
+		},
+		{
+			name:    "removed key",
+			meta:    map[string]interface{}{"series": nil},
+			want:    strings.Replace(original, "series: intro\n", "", 1),
+			changed: []string{"series"},
+		},
+		{
+			name:    "new key appended",
+			meta:    map[string]interface{}{"draft": true},
+			want:    strings.Replace(original, "series: intro\n", "series: intro\ndraft: true\n", 1),
+			changed: []string{"draft"},
+		},
+		{
+			name:    "body only",
+			body:    "New body.",
+			want:    strings.Replace(original, "Body.", "New body.", 1),
+			changed: []string{"body"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			store, root := newTestBlog(t)
+			path := writeTestPost(t, root, rel, original)
+
+			args := map[string]interface{}{"path": rel}
+			if tt.meta != nil {
+				args["meta"] = tt.meta
+			}
+			if tt.body != "" {
+				args["body"] = tt.body
+			}
+			output := toolResult(t, callTool(t, store, "bckt_update", args)).StructuredContent.(UpdateOutput)
+
+			if strings.Join(output.Changed, ",") != strings.Join(tt.changed, ",") {
+				t.Errorf("changed = %v, want %v", output.Changed, tt.changed)
+			}
+			if got := readTestFile(t, path); got != tt.want {
+				t.Errorf("updated post =\n%s\nwant\n%s", got, tt.want)
+			}
+		})
+	}
+}
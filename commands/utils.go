@@ -2,10 +2,12 @@ package commands
 
 import (
 	"bytes"
+	"context"
 	"fmt"
 	"os"
 	"path/filepath"
-	"regexp"
+	"reflect"
+	"sort"
 	"strings"
 	"time"
 
@@ -28,14 +30,27 @@ func GetDefaultConfig() Config {
 	cfg.RootPath = "" // Must be set by user on first save
 	cfg.Timezone = "UTC"
 	cfg.PathPattern = "posts/{yyyy}/{yyyy}-{MM}-{DD}-{slug}/{slug}.md"
+	cfg.DraftsPattern = "drafts/{slug}/{slug}.md"
+	cfg.ScheduledPattern = "scheduled/{yyyy}-{MM}-{DD}-{slug}/{slug}.md"
 	cfg.FrontMatter.Required = []string{"title", "slug", "date", "tags", "abstract", "lang"}
 	cfg.FrontMatter.Defaults = map[string]interface{}{
 		"lang": "en",
 	}
 	cfg.MarkdownRule.WrapAt = 100
+	cfg.Slug.Transliterate = map[string]string{}
+	cfg.Slug.Fallback = "unicode"
+	cfg.Backup.Dir = "" // Defaults to backups/ next to config.toml
+	cfg.Backup.Keep = 10
+	cfg.Timeouts.Default = 30
 	return cfg
 }
 
+// GlobalConfigPath returns the location of config.toml.
+func GlobalConfigPath() string {
+	homeDir, _ := os.UserHomeDir()
+	return filepath.Join(homeDir, ".config", "bckt-mcp", "config.toml")
+}
+
 func LoadGlobalConfig() *Config {
 	// Try to load from ~/.config/bckt-mcp/config.toml
 	homeDir, err := os.UserHomeDir()
@@ -62,10 +77,38 @@ func LoadGlobalConfig() *Config {
 		return nil
 	}
 
+	if verr := cfg.validate(); verr != nil {
+		for _, fe := range verr.Errors {
+			fmt.Fprintf(os.Stderr, "Warning: Failed to load config from %s: %s: %s\n", configPath, strings.TrimPrefix(fe.Pointer, "/"), fe.Message)
+		}
+		return nil
+	}
+
 	fmt.Fprintf(os.Stderr, "Loaded config from: %s\n", configPath)
+	if verr := cfg.checkPatterns(); verr != nil {
+		for _, fe := range verr.Errors {
+			fmt.Fprintf(os.Stderr, "Warning: %s in %s: %s\n", strings.TrimPrefix(fe.Pointer, "/"), configPath, fe.Message)
+		}
+	}
 	return &cfg
 }
 
+// validate checks the settings a config cannot be used without: the front
+// matter schema and the slug options. Unlike bad path patterns, which only
+// break the tools that use them, these are rejected when the config is loaded.
+func (c Config) validate() *ValidationError {
+	verr := &ValidationError{}
+	for _, check := range []func() *ValidationError{c.checkSchema, c.checkSlug} {
+		if e := check(); e != nil {
+			verr.Errors = append(verr.Errors, e.Errors...)
+		}
+	}
+	if len(verr.Errors) > 0 {
+		return verr
+	}
+	return nil
+}
+
 func SaveGlobalConfig(path string, cfg *Config) error {
 	// Create directory if needed
 	dir := filepath.Dir(path)
@@ -73,31 +116,31 @@ func SaveGlobalConfig(path string, cfg *Config) error {
 		return err
 	}
 
-	// Create file
-	f, err := os.Create(path)
-	if err != nil {
+	// Encode TOML
+	var buf bytes.Buffer
+	encoder := toml.NewEncoder(&buf)
+	if err := encoder.Encode(cfg); err != nil {
 		return err
 	}
-	defer f.Close()
 
-	// Write TOML
-	encoder := toml.NewEncoder(f)
-	return encoder.Encode(cfg)
+	// Write through a temp file so a failed write never truncates the config
+	return writeFileWithBackup(*cfg, path, buf.Bytes())
 }
 
-func FormatContent(input FormatInput, globalConfig *Config) (*FormatOutput, error) {
-	// Start with global config or defaults
-	var cfg Config
-	if globalConfig != nil {
-		cfg = *globalConfig
-	} else {
-		cfg = GetDefaultConfig()
-	}
-
+func FormatContent(ctx context.Context, input FormatInput, cfg Config) (*FormatOutput, error) {
 	// Override with inline config if provided
 	if input.Config != "" {
-		if err := toml.Unmarshal([]byte(input.Config), &cfg); err == nil {
-			// Config loaded successfully
+		if err := toml.Unmarshal([]byte(input.Config), &cfg); err != nil {
+			verr := &ValidationError{}
+			verr.add("/config", "invalid TOML: %v", err).Hint =
+				"Pass settings as TOML, e.g. path_pattern = \"posts/{slug}.md\", or leave config out"
+			return nil, verr
+		}
+		if verr := cfg.checkPatterns(); verr != nil {
+			return nil, verr.under("/config")
+		}
+		if verr := cfg.validate(); verr != nil {
+			return nil, verr.under("/config")
 		}
 	}
 
@@ -117,23 +160,26 @@ func FormatContent(input FormatInput, globalConfig *Config) (*FormatOutput, erro
 	// Validate title
 	title, ok := frontMatter["title"].(string)
 	if !ok || strings.TrimSpace(title) == "" {
-		return nil, fmt.Errorf("title is required")
+		verr := &ValidationError{}
+		verr.add("/meta/title", "is required and must not be empty").Hint = "Ask the user for the post title"
+		return nil, verr
 	}
 
+	schema := frontMatterSchema(cfg)
+
 	// Auto-generate slug if missing
-	if _, ok := frontMatter["slug"]; !ok {
-		frontMatter["slug"] = slugify(title)
+	if _, ok := frontMatter["slug"]; !ok && schema["slug"].auto() {
+		lang, _ := frontMatter["lang"].(string)
+		slug, err := slugify(title, lang, cfg)
+		if err != nil {
+			return nil, err
+		}
+		frontMatter["slug"] = slug
 	}
 
 	// Auto-generate date if missing
-	if _, ok := frontMatter["date"]; !ok {
-		// Load timezone
-		loc, err := time.LoadLocation(cfg.Timezone)
-		if err != nil {
-			loc = time.UTC
-		}
-		// Format: "2006-01-02 15:04:05 -0700"
-		frontMatter["date"] = time.Now().In(loc).Format("2006-01-02 15:04:05 -0700")
+	if _, ok := frontMatter["date"]; !ok && schema["date"].auto() {
+		frontMatter["date"] = time.Now().In(cfg.location()).Format(dateLayout)
 	}
 
 	// Ensure required fields have defaults
@@ -144,11 +190,40 @@ func FormatContent(input FormatInput, globalConfig *Config) (*FormatOutput, erro
 		frontMatter["abstract"] = ""
 	}
 
+	// Store the date in one format, in the configured timezone
+	if s, ok := frontMatter["date"].(string); ok {
+		date, err := parseDate(s, cfg.location(), time.Now())
+		if err != nil {
+			verr := &ValidationError{}
+			fe := verr.add("/meta/date", "%v", err)
+			fe.Expected = "date"
+			fe.Hint = dateHint + ", or leave it out to use the current time"
+			return nil, verr
+		}
+		frontMatter["date"] = date.In(cfg.location()).Format(dateLayout)
+	}
+
 	// Validate front matter
-	warnings, err := validateFrontMatter(frontMatter, cfg, input.Strategy != "lenient")
+	strict := input.Strategy != "lenient"
+	warnings, err := validateFrontMatter(frontMatter, cfg, strict)
+	if verr, ok := err.(*ValidationError); ok {
+		return nil, verr.under("/meta")
+	}
+
+	if isScheduled(cfg, frontMatter, time.Now()) && !isDraft(frontMatter) {
+		warnings = append(warnings, fmt.Sprintf("date %v is in the future: the post goes to the scheduled queue until bckt_publish_due moves it into place", frontMatter["date"]))
+	}
+
+	// Rewrite tags to their canonical forms
+	registry, err := loadTagRegistry(ctx, cfg)
 	if err != nil {
 		return nil, err
 	}
+	tagWarnings, verr := normalizeTags(frontMatter, registry, strict && cfg.Tags.RejectUnknown)
+	if verr != nil {
+		return nil, verr.under("/meta")
+	}
+	warnings = append(warnings, tagWarnings...)
 
 	// Wrap abstract if present
 	if abstract, ok := frontMatter["abstract"].(string); ok && abstract != "" {
@@ -158,131 +233,211 @@ func FormatContent(input FormatInput, globalConfig *Config) (*FormatOutput, erro
 	// Format body text
 	body := wrapText(input.Raw, cfg.MarkdownRule.WrapAt)
 
+	markdown, err := renderPost(frontMatter, body)
+	if err != nil {
+		return nil, err
+	}
+
+	fullPath, err := computePostPath(cfg, frontMatter)
+	if err != nil {
+		return nil, err
+	}
+
+	return &FormatOutput{
+		Path:     fullPath,
+		Markdown: markdown,
+		Warnings: warnings,
+	}, nil
+}
+
+// renderPost assembles the final markdown from front matter and body.
+func renderPost(frontMatter map[string]interface{}, body string) (string, error) {
 	// Generate YAML front matter with literal style for multiline fields
 	var yamlBuf bytes.Buffer
 	encoder := yaml.NewEncoder(&yamlBuf)
 	encoder.SetIndent(2)
 	if err := encoder.Encode(frontMatter); err != nil {
-		return nil, fmt.Errorf("failed to generate YAML: %v", err)
+		return "", fmt.Errorf("failed to generate YAML: %v", err)
 	}
 	encoder.Close()
 	yamlData := yamlBuf.Bytes()
 
 	// Assemble final markdown
-	markdown := fmt.Sprintf("---\n%s---\n\n%s\n", string(yamlData), strings.TrimRight(body, "\n"))
-
-	// Compute path
-	dateStr := frontMatter["date"].(string)
-	slug := frontMatter["slug"].(string)
-	relativePath := computePath(cfg.PathPattern, dateStr, slug)
+	return fmt.Sprintf("---\n%s---\n\n%s\n", string(yamlData), strings.TrimRight(body, "\n")), nil
+}
 
-	// Prepend root path if configured
-	fullPath := relativePath
-	if cfg.RootPath != "" {
-		fullPath = filepath.Join(cfg.RootPath, relativePath)
+// rewritePost renders a changed version of the post raw with the given
+// front matter and body. Unlike renderPost it edits raw's front matter in
+// place: keys keep their order, comments and quoting, only the keys whose
+// values changed are rewritten, removed keys are dropped and new ones are
+// appended.
+func rewritePost(raw string, frontMatter map[string]interface{}, body string) (string, error) {
+	yamlPart, _, err := splitFrontMatter([]byte(raw))
+	if err != nil {
+		return "", err
 	}
+	var doc yaml.Node
+	if err := yaml.Unmarshal([]byte(yamlPart), &doc); err != nil {
+		return "", fmt.Errorf("invalid front matter: %v", err)
+	}
+	if len(doc.Content) == 0 || doc.Content[0].Kind != yaml.MappingNode {
+		return renderPost(frontMatter, body)
+	}
+	mapping := doc.Content[0]
+
+	seen := make(map[string]bool, len(frontMatter))
+	var content []*yaml.Node
+	for i := 0; i+1 < len(mapping.Content); i += 2 {
+		key, value := mapping.Content[i], mapping.Content[i+1]
+		newValue, ok := frontMatter[key.Value]
+		if !ok || seen[key.Value] {
+			continue // Removed
+		}
+		seen[key.Value] = true
 
-	return &FormatOutput{
-		Path:     fullPath,
-		Markdown: markdown,
-		Warnings: warnings,
-	}, nil
-}
-
-func validateFrontMatter(fm map[string]interface{}, cfg Config, strict bool) ([]string, error) {
-	required := make(map[string]bool)
-	for _, field := range cfg.FrontMatter.Required {
-		required[field] = true
-		if _, ok := fm[field]; !ok {
-			return nil, fmt.Errorf("missing required field: %s", field)
+		var node yaml.Node
+		if err := node.Encode(newValue); err != nil {
+			return "", fmt.Errorf("failed to generate YAML for %s: %v", key.Value, err)
+		}
+		if !sameYAML(value, &node) {
+			keepStyle(value, &node)
+			value = &node
 		}
+		content = append(content, key, value)
 	}
 
-	var warnings []string
-	if strict {
-		for key := range fm {
-			if !required[key] {
-				return nil, fmt.Errorf("unknown field in strict mode: %s", key)
-			}
+	var added []string
+	for k := range frontMatter {
+		if !seen[k] {
+			added = append(added, k)
 		}
-	} else {
-		for key := range fm {
-			if !required[key] {
-				warnings = append(warnings, fmt.Sprintf("unknown field: %s", key))
-			}
+	}
+	sort.Strings(added)
+	for _, k := range added {
+		var key, value yaml.Node
+		key.SetString(k)
+		if err := value.Encode(frontMatter[k]); err != nil {
+			return "", fmt.Errorf("failed to generate YAML for %s: %v", k, err)
 		}
+		content = append(content, &key, &value)
 	}
+	mapping.Content = content
 
-	return warnings, nil
-}
+	var yamlBuf bytes.Buffer
+	encoder := yaml.NewEncoder(&yamlBuf)
+	encoder.SetIndent(2)
+	if err := encoder.Encode(&doc); err != nil {
+		return "", fmt.Errorf("failed to generate YAML: %v", err)
+	}
+	encoder.Close()
 
-func slugify(s string) string {
-	s = strings.ToLower(s)
-	re := regexp.MustCompile(`[^a-z0-9]+`)
-	s = re.ReplaceAllString(s, "-")
-	return strings.Trim(s, "-")
+	return fmt.Sprintf("---\n%s---\n\n%s\n", yamlBuf.String(), strings.TrimRight(body, "\n")), nil
 }
 
-func wrapText(text string, width int) string {
-	if width < 20 {
-		return text
+// sameYAML reports whether two nodes hold the same value, whatever their
+// style.
+func sameYAML(a, b *yaml.Node) bool {
+	var va, vb interface{}
+	if a.Decode(&va) != nil || b.Decode(&vb) != nil {
+		return false
 	}
+	return reflect.DeepEqual(va, vb)
+}
 
-	var result []string
-	lines := strings.Split(text, "\n")
-
-	for _, line := range lines {
-		if len(line) <= width {
-			result = append(result, line)
-			continue
-		}
-
-		words := strings.Fields(line)
-		var current strings.Builder
-
-		for _, word := range words {
-			if current.Len() == 0 {
-				current.WriteString(word)
-			} else if current.Len()+1+len(word) <= width {
-				current.WriteString(" ")
-				current.WriteString(word)
-			} else {
-				result = append(result, current.String())
-				current.Reset()
-				current.WriteString(word)
-			}
+// keepStyle carries the quoting, flow style and comments of a replaced value
+// over to its replacement.
+func keepStyle(old, replacement *yaml.Node) {
+	replacement.HeadComment = old.HeadComment
+	replacement.LineComment = old.LineComment
+	replacement.FootComment = old.FootComment
+	if old.Kind != replacement.Kind {
+		return
+	}
+	switch old.Kind {
+	case yaml.ScalarNode:
+		// Only quoting, which keeps a string a string; multiline text keeps
+		// the literal style the encoder picks for it
+		if replacement.Tag == "!!str" && !strings.Contains(replacement.Value, "\n") &&
+			(old.Style == yaml.DoubleQuotedStyle || old.Style == yaml.SingleQuotedStyle) {
+			replacement.Style = old.Style
 		}
+	case yaml.SequenceNode, yaml.MappingNode:
+		replacement.Style |= old.Style & yaml.FlowStyle
+	}
+}
 
-		if current.Len() > 0 {
-			result = append(result, current.String())
-		}
+// computePostPath returns the path of a post from its front matter, prefixed
+// with root_path when configured. Drafts use the drafts pattern.
+func computePostPath(cfg Config, frontMatter map[string]interface{}) (string, error) {
+	relativePath, err := renderPattern(cfg, postPattern(cfg, frontMatter), frontMatter)
+	if err != nil {
+		return "", err
 	}
 
-	return strings.Join(result, "\n")
+	// Prepend root path if configured
+	if cfg.RootPath != "" {
+		return filepath.Join(cfg.RootPath, relativePath), nil
+	}
+	return relativePath, nil
 }
 
-func computePath(pattern, date, slug string) string {
-	// Date format: "2006-01-02 15:04:05 -0700" or RFC3339
-	// Extract yyyy-MM-dd part
-	datePart := date
-	if len(date) >= 10 {
-		datePart = date[:10] // Get "2025-10-06"
+// postPattern returns the pattern a post's path follows: the drafts
+// pattern for drafts, the scheduled pattern for posts dated in the future
+// and the path pattern otherwise.
+func postPattern(cfg Config, frontMatter map[string]interface{}) string {
+	if isDraft(frontMatter) {
+		return cfg.draftsPattern()
+	}
+	if isScheduled(cfg, frontMatter, time.Now()) {
+		return cfg.scheduledPattern()
 	}
+	return cfg.PathPattern
+}
 
-	parts := strings.Split(datePart, "-")
-	if len(parts) < 3 {
-		// Fallback if date format is unexpected
-		return pattern
+// isScheduled reports whether a post is dated after now.
+func isScheduled(cfg Config, frontMatter map[string]interface{}, now time.Time) bool {
+	date, ok := parseDateValue(frontMatter["date"], cfg.location())
+	return ok && date.After(now)
+}
+
+// ToolTimeout returns how long a tool may run, from [timeouts]. Zero means
+// the default applies; a negative value turns the limit off.
+func (c Config) ToolTimeout(tool string) time.Duration {
+	seconds, ok := c.Timeouts.Tools[tool]
+	if !ok || seconds == 0 {
+		seconds = c.Timeouts.Default
+	}
+	if seconds == 0 {
+		seconds = GetDefaultConfig().Timeouts.Default
 	}
+	if seconds < 0 {
+		return 0
+	}
+	return time.Duration(seconds) * time.Second
+}
 
-	yyyy := parts[0]
-	mm := parts[1]
-	dd := parts[2]
+// draftsPattern returns drafts_pattern, or the default when it isn't set.
+func (c Config) draftsPattern() string {
+	if c.DraftsPattern == "" {
+		return GetDefaultConfig().DraftsPattern
+	}
+	return c.DraftsPattern
+}
 
-	path := strings.ReplaceAll(pattern, "{yyyy}", yyyy)
-	path = strings.ReplaceAll(path, "{MM}", mm)
-	path = strings.ReplaceAll(path, "{DD}", dd)
-	path = strings.ReplaceAll(path, "{slug}", slug)
+// scheduledPattern returns scheduled_pattern, or the default when it isn't
+// set.
+func (c Config) scheduledPattern() string {
+	if c.ScheduledPattern == "" {
+		return GetDefaultConfig().ScheduledPattern
+	}
+	return c.ScheduledPattern
+}
 
-	return path
+// location returns the configured timezone, falling back to UTC.
+func (c Config) location() *time.Location {
+	loc, err := time.LoadLocation(c.Timezone)
+	if err != nil {
+		return time.UTC
+	}
+	return loc
 }
@@ -0,0 +1,448 @@
+package commands
+
+import (
+	"regexp"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+)
+
+var (
+	listItemRe  = regexp.MustCompile(`^[ \t]*([-*+]|\d{1,9}[.)])[ \t]+(\[[ xX]\][ \t]+)?`)
+	quoteRe     = regexp.MustCompile(`^[ ]{0,3}(>[ ]?)+`)
+	fenceRe     = regexp.MustCompile("^(`{3,}|~{3,})")
+	headingRe   = regexp.MustCompile(`^#{1,6}([ \t]|$)`)
+	ruleRe      = regexp.MustCompile(`^(([-*_])[ \t]*){3,}$|^=+$`)
+	linkDefRe   = regexp.MustCompile(`^\[[^\]]+\]:[ \t]`)
+	htmlBlockRe = regexp.MustCompile(`^<(/?[A-Za-z][A-Za-z0-9-]*([ \t/>]|$)|!--|![A-Z]|\?)`)
+)
+
+// wrapText wraps paragraph text to width. Markdown structure is left alone:
+// front matter, fenced and indented code, math and HTML blocks, tables,
+// headings and rules are copied as-is, list and quote prefixes are repeated
+// on continuation lines, and inline code and links are never split.
+func wrapText(text string, width int) string {
+	if width < 20 {
+		return text
+	}
+
+	lines := strings.Split(text, "\n")
+	var result []string
+
+	prevBlank := true
+	inTable := false
+	inList := false
+	listIndent := 0
+
+	// copyUntil copies lines verbatim from i up to and including the first
+	// line after i for which end returns true, and returns its index.
+	copyUntil := func(i int, end func(line string) bool) int {
+		result = append(result, lines[i])
+		for j := i + 1; j < len(lines); j++ {
+			result = append(result, lines[j])
+			if end(lines[j]) {
+				return j
+			}
+		}
+		return len(lines) - 1
+	}
+
+	for i := 0; i < len(lines); i++ {
+		line := lines[i]
+		inner := strings.TrimSpace(quoteRe.ReplaceAllString(line, ""))
+
+		// Front matter
+		if i == 0 && strings.TrimSpace(line) == "---" {
+			i = copyUntil(i, func(l string) bool {
+				l = strings.TrimSpace(l)
+				return l == "---" || l == "..."
+			})
+			continue
+		}
+
+		// Blank lines
+		if strings.TrimSpace(line) == "" {
+			result = append(result, line)
+			prevBlank = true
+			inTable = false
+			continue
+		}
+
+		// An unindented line after a blank line ends a list
+		if prevBlank && indentWidth(line) == 0 && !listItemRe.MatchString(line) {
+			inList = false
+		}
+
+		// Fenced code blocks
+		if fence := fenceRe.FindString(inner); fence != "" {
+			i = copyUntil(i, func(l string) bool {
+				l = strings.TrimSpace(quoteRe.ReplaceAllString(l, ""))
+				return strings.HasPrefix(l, fence) && strings.Trim(l, fence[:1]) == ""
+			})
+			prevBlank = false
+			continue
+		}
+
+		// Math blocks
+		if strings.HasPrefix(inner, "$$") || inner == `\[` {
+			closing := "$$"
+			if inner == `\[` {
+				closing = `\]`
+			}
+			if len(inner) > 2 && strings.HasSuffix(inner, closing) {
+				result = append(result, line)
+			} else {
+				i = copyUntil(i, func(l string) bool {
+					return strings.HasSuffix(strings.TrimSpace(l), closing)
+				})
+			}
+			prevBlank = false
+			continue
+		}
+
+		// HTML blocks run until the next blank line
+		if prevBlank && htmlBlockRe.MatchString(inner) {
+			end := func(l string) bool { return strings.TrimSpace(l) == "" }
+			if strings.HasPrefix(inner, "<!--") {
+				end = func(l string) bool { return strings.Contains(l, "-->") }
+				if strings.Contains(inner, "-->") {
+					result = append(result, line)
+					prevBlank = false
+					continue
+				}
+			}
+			i = copyUntil(i, end)
+			prevBlank = strings.TrimSpace(lines[i]) == ""
+			continue
+		}
+
+		// Indented code blocks, which cannot interrupt a paragraph
+		indent := indentWidth(line)
+		codeIndent := 4
+		if inList {
+			codeIndent = listIndent + 4
+		}
+		if prevBlank && indent >= codeIndent {
+			result = append(result, line)
+			for i+1 < len(lines) && (strings.TrimSpace(lines[i+1]) == "" || indentWidth(lines[i+1]) >= codeIndent) {
+				i++
+				result = append(result, lines[i])
+			}
+			prevBlank = strings.TrimSpace(lines[i]) == ""
+			continue
+		}
+
+		// Tables
+		nextIsSeparator := i+1 < len(lines) && isTableSeparator(strings.TrimSpace(quoteRe.ReplaceAllString(lines[i+1], "")))
+		if inTable || strings.HasPrefix(inner, "|") || isTableSeparator(inner) || (strings.Contains(inner, "|") && nextIsSeparator) {
+			result = append(result, line)
+			inTable = true
+			prevBlank = false
+			continue
+		}
+
+		// Headings, rules and link reference definitions
+		if headingRe.MatchString(inner) || ruleRe.MatchString(inner) || linkDefRe.MatchString(inner) {
+			result = append(result, line)
+			prevBlank = false
+			continue
+		}
+
+		// Paragraph text, possibly inside a quote and/or list item
+		quote := quoteRe.FindString(line)
+		content := line[len(quote):]
+		first, rest := quote, quote
+
+		if item := listItemRe.FindString(content); item != "" {
+			first += item
+			rest += strings.Repeat(" ", displayWidth(item))
+			content = content[len(item):]
+			if quote == "" {
+				inList = true
+				listIndent = displayWidth(item)
+			}
+		} else {
+			lead := content[:len(content)-len(strings.TrimLeft(content, " \t"))]
+			first += lead
+			rest += lead
+			content = content[len(lead):]
+		}
+
+		result = append(result, wrapLine(line, first, rest, content, width)...)
+		prevBlank = false
+	}
+
+	return strings.Join(result, "\n")
+}
+
+// wrapLine fills content into lines no wider than width. The first line
+// starts with first, the following ones with rest.
+func wrapLine(line, first, rest, content string, width int) []string {
+	if displayWidth(line) <= width {
+		return []string{line}
+	}
+
+	// Keep hard line breaks at the end of the last line
+	hardBreak := ""
+	if strings.HasSuffix(content, "  ") {
+		hardBreak = "  "
+	} else if strings.HasSuffix(content, `\`) && !strings.HasSuffix(content, `\\`) {
+		hardBreak = `\`
+	}
+	content = strings.TrimSuffix(strings.TrimRight(content, " \t"), hardBreak)
+
+	var result []string
+	prefix := first
+	var current strings.Builder
+	currentWidth := 0
+
+	for _, word := range splitWords(content) {
+		available := width - displayWidth(prefix)
+		wordWidth := displayWidth(word.text)
+		separator := ""
+		if word.space {
+			separator = " "
+		}
+
+		switch {
+		case current.Len() == 0:
+			current.WriteString(word.text)
+			currentWidth = wordWidth
+		case currentWidth+len(separator)+wordWidth <= available || startsBlock(word.text):
+			// A word that would start a new block stays on this line
+			current.WriteString(separator)
+			current.WriteString(word.text)
+			currentWidth += len(separator) + wordWidth
+		default:
+			result = append(result, prefix+current.String())
+			prefix = rest
+			current.Reset()
+			current.WriteString(word.text)
+			currentWidth = wordWidth
+		}
+	}
+
+	if current.Len() > 0 {
+		result = append(result, prefix+current.String()+hardBreak)
+	}
+
+	return result
+}
+
+type wrapWord struct {
+	text  string
+	space bool // Separated from the previous word by whitespace
+}
+
+// splitWords splits text at whitespace, keeping inline code spans, links
+// and images together as single words. Wide (CJK) characters can be broken
+// between without whitespace.
+func splitWords(s string) []wrapWord {
+	var words []wrapWord
+	var current strings.Builder
+	space := false
+	var last rune
+
+	flush := func() {
+		if current.Len() > 0 {
+			words = append(words, wrapWord{text: current.String(), space: space})
+			current.Reset()
+			space = false
+		}
+	}
+
+	for i := 0; i < len(s); {
+		c := s[i]
+		switch {
+		case c == ' ' || c == '\t':
+			flush()
+			space = len(words) > 0
+			i++
+		case c == '\\' && i+1 < len(s):
+			current.WriteString(s[i : i+2])
+			i += 2
+		case c == '`':
+			end := codeSpanEnd(s, i)
+			current.WriteString(s[i:end])
+			i = end
+		case c == '[' || (c == '!' && i+1 < len(s) && s[i+1] == '['):
+			end := linkEnd(s, i)
+			if end < 0 {
+				current.WriteByte(c)
+				i++
+				continue
+			}
+			current.WriteString(s[i:end])
+			i = end
+		default:
+			r, size := utf8.DecodeRuneInString(s[i:])
+			if current.Len() > 0 && (isWide(r) || isWide(last)) && canBreakBefore(r) && canBreakAfter(last) {
+				flush()
+			}
+			current.WriteString(s[i : i+size])
+			last = r
+			i += size
+			continue
+		}
+		last = 0
+	}
+	flush()
+
+	return words
+}
+
+// codeSpanEnd returns the index after the code span starting at i, or after
+// the opening backticks when the span is not closed.
+func codeSpanEnd(s string, i int) int {
+	n := 0
+	for i+n < len(s) && s[i+n] == '`' {
+		n++
+	}
+	fence := s[i : i+n]
+
+	for j := i + n; j < len(s); {
+		k := strings.Index(s[j:], fence)
+		if k < 0 {
+			break
+		}
+		k += j
+		// The closing run must have exactly the same length
+		end := k + n
+		if end < len(s) && s[end] == '`' {
+			for end < len(s) && s[end] == '`' {
+				end++
+			}
+			j = end
+			continue
+		}
+		return end
+	}
+
+	return i + n
+}
+
+// linkEnd returns the index after the link or image starting at i, or -1
+// when the brackets are not balanced.
+func linkEnd(s string, i int) int {
+	if s[i] == '!' {
+		i++
+	}
+
+	closeBracket := matchingEnd(s, i, '[', ']')
+	if closeBracket < 0 {
+		return -1
+	}
+
+	next := closeBracket + 1
+	if next < len(s) {
+		switch s[next] {
+		case '(':
+			if end := matchingEnd(s, next, '(', ')'); end >= 0 {
+				return end + 1
+			}
+		case '[':
+			if end := matchingEnd(s, next, '[', ']'); end >= 0 {
+				return end + 1
+			}
+		}
+	}
+
+	return next
+}
+
+// matchingEnd returns the index of the bracket closing the one at i,
+// skipping escapes and code spans.
+func matchingEnd(s string, i int, open, close byte) int {
+	depth := 0
+	for j := i; j < len(s); j++ {
+		switch s[j] {
+		case '\\':
+			j++
+		case '`':
+			j = codeSpanEnd(s, j) - 1
+		case open:
+			depth++
+		case close:
+			depth--
+			if depth == 0 {
+				return j
+			}
+		}
+	}
+	return -1
+}
+
+// startsBlock reports whether a word at the start of a line would be read
+// as a list item, heading, quote or table instead of paragraph text.
+func startsBlock(word string) bool {
+	return listItemRe.MatchString(word+" ") || headingRe.MatchString(word) ||
+		strings.HasPrefix(word, ">") || strings.HasPrefix(word, "|") || ruleRe.MatchString(word)
+}
+
+func isTableSeparator(line string) bool {
+	if !strings.Contains(line, "|") || !strings.Contains(line, "-") {
+		return false
+	}
+	return strings.Trim(line, "|-: \t") == ""
+}
+
+// indentWidth returns the width of the leading whitespace, counting tabs as 4.
+func indentWidth(line string) int {
+	return displayWidth(line[:len(line)-len(strings.TrimLeft(line, " \t"))])
+}
+
+// displayWidth returns the number of terminal columns s takes: wide East
+// Asian characters count as 2, combining marks as 0 and tabs expand to 4.
+func displayWidth(s string) int {
+	width := 0
+	for _, r := range s {
+		switch {
+		case r == '\t':
+			width += 4 - width%4
+		case r == 0x200B || r == 0x200C || r == 0x200D || r == 0xFEFF ||
+			unicode.In(r, unicode.Mn, unicode.Me) || unicode.Is(unicode.Variation_Selector, r):
+			// Zero width
+		case isWide(r):
+			width += 2
+		default:
+			width++
+		}
+	}
+	return width
+}
+
+// isWide reports whether r is an East Asian wide or fullwidth character.
+func isWide(r rune) bool {
+	switch {
+	case r < 0x1100:
+		return false
+	case r <= 0x115F, // Hangul Jamo
+		r >= 0x2E80 && r <= 0x303E, // CJK radicals, punctuation
+		r >= 0x3041 && r <= 0x33FF, // Kana, CJK compatibility
+		r >= 0x3400 && r <= 0x4DBF, // CJK extension A
+		r >= 0x4E00 && r <= 0x9FFF, // CJK unified ideographs
+		r >= 0xA000 && r <= 0xA4CF, // Yi
+		r >= 0xAC00 && r <= 0xD7A3, // Hangul syllables
+		r >= 0xF900 && r <= 0xFAFF, // CJK compatibility ideographs
+		r >= 0xFE30 && r <= 0xFE4F, // CJK compatibility forms
+		r >= 0xFF00 && r <= 0xFF60, // Fullwidth forms
+		r >= 0xFFE0 && r <= 0xFFE6,
+		r >= 0x1F300 && r <= 0x1F64F, // Emoji
+		r >= 0x1F900 && r <= 0x1F9FF,
+		r >= 0x20000 && r <= 0x3FFFD: // CJK extensions B and later
+		return true
+	}
+	return false
+}
+
+// canBreakBefore reports whether a line may start with r. Closing
+// punctuation must stay with the preceding character.
+func canBreakBefore(r rune) bool {
+	return !strings.ContainsRune("、。，．・：；？！）」』】〕〉》〗〙〛ー々ゝゞヽヾぁぃぅぇぉっゃゅょゎァィゥェォッャュョヮヵヶ,.:;?!)]}", r)
+}
+
+// canBreakAfter reports whether a line may end with r. Opening punctuation
+// must stay with the following character.
+func canBreakAfter(r rune) bool {
+	return !strings.ContainsRune("（「『【〔〈《〖〘〚([{", r)
+}
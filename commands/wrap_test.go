@@ -0,0 +1,112 @@
+package commands
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestWrapText(t *testing.T) {
+	// Lines are joined with \n, to keep the cases readable
+	lines := func(l ...string) string { return strings.Join(l, "\n") }
+
+	tests := []struct {
+		name  string
+		width int
+		text  string
+		want  string
+	}{
+		{
+			"short line", 30,
+			"Nothing to wrap here.",
+			"Nothing to wrap here.",
+		},
+		{
+			"paragraph", 30,
+			"The quick brown fox jumps over the lazy dog and keeps on running.",
+			lines("The quick brown fox jumps over", "the lazy dog and keeps on", "running."),
+		},
+		{
+			"width below minimum", 10,
+			"The quick brown fox jumps over the lazy dog.",
+			"The quick brown fox jumps over the lazy dog.",
+		},
+		{
+			"long word", 20,
+			"see https://example.com/a/very/long/path/indeed ok",
+			lines("see", "https://example.com/a/very/long/path/indeed", "ok"),
+		},
+		{
+			"inline code not split", 30,
+			"Run `go test ./... -run TestWrapText` to check.",
+			lines("Run", "`go test ./... -run TestWrapText`", "to check."),
+		},
+		{
+			"link not split", 30,
+			"Read [the whole manual](https://example.com) first.",
+			lines("Read", "[the whole manual](https://example.com)", "first."),
+		},
+		{
+			"list item", 30,
+			"- The quick brown fox jumps over the lazy dog.",
+			lines("- The quick brown fox jumps", "  over the lazy dog."),
+		},
+		{
+			"numbered task item", 30,
+			"1. [x] The quick brown fox jumps over the dog.",
+			lines("1. [x] The quick brown fox", "       jumps over the dog."),
+		},
+		{
+			"quote", 30,
+			"> The quick brown fox jumps over the lazy dog.",
+			lines("> The quick brown fox jumps", "> over the lazy dog."),
+		},
+		{
+			"heading kept", 20,
+			"# A heading that is much longer than the width",
+			"# A heading that is much longer than the width",
+		},
+		{
+			"fenced code kept", 20,
+			lines("```go", "fmt.Println(\"a line of code longer than the width\")", "```"),
+			lines("```go", "fmt.Println(\"a line of code longer than the width\")", "```"),
+		},
+		{
+			"indented code kept", 20,
+			lines("Code:", "", "    fmt.Println(\"a line of code longer than the width\")"),
+			lines("Code:", "", "    fmt.Println(\"a line of code longer than the width\")"),
+		},
+		{
+			"table kept", 20,
+			lines("| Column one | Column two | Column three |", "|---|---|---|", "| a | b | c |"),
+			lines("| Column one | Column two | Column three |", "|---|---|---|", "| a | b | c |"),
+		},
+		{
+			"math kept", 20,
+			lines("$$", "a^2 + b^2 = c^2 \\quad \\text{for every right triangle}", "$$"),
+			lines("$$", "a^2 + b^2 = c^2 \\quad \\text{for every right triangle}", "$$"),
+		},
+		{
+			"html kept", 20,
+			`<div class="note">A block of HTML longer than the width</div>`,
+			`<div class="note">A block of HTML longer than the width</div>`,
+		},
+		{
+			"front matter kept", 20,
+			lines("---", "title: A title longer than the width of the text", "---", "", "Body."),
+			lines("---", "title: A title longer than the width of the text", "---", "", "Body."),
+		},
+		{
+			"wide characters", 20,
+			"日本語の文章はスペースがなくても折り返されます",
+			lines("日本語の文章はスペー", "スがなくても折り返さ", "れます"),
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := wrapText(tt.text, tt.width); got != tt.want {
+				t.Errorf("wrapText(%q, %d) =\n%s\nwant\n%s", tt.text, tt.width, got, tt.want)
+			}
+		})
+	}
+}
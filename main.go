@@ -2,11 +2,22 @@ package main
 
 import (
 	"bufio"
+	"bytes"
+	"context"
+	"crypto/rand"
+	"crypto/subtle"
+	"encoding/hex"
 	"encoding/json"
+	"flag"
 	"fmt"
 	"io"
+	"net/http"
+	"net/url"
 	"os"
+	"strconv"
 	"strings"
+	"sync"
+	"time"
 
 	"bckt-mcp/commands"
 )
@@ -16,14 +27,14 @@ var version = "dev"
 // JSON-RPC 2.0 structures (main protocol only)
 type Request struct {
 	JSONRPC string          `json:"jsonrpc"`
-	ID      interface{}     `json:"id,omitempty"`
+	ID      json.RawMessage `json:"id,omitempty"` // Absent for notifications
 	Method  string          `json:"method"`
 	Params  json.RawMessage `json:"params,omitempty"`
 }
 
 type Response struct {
 	JSONRPC string      `json:"jsonrpc"`
-	ID      interface{} `json:"id,omitempty"`
+	ID      interface{} `json:"id"`
 	Result  interface{} `json:"result,omitempty"`
 	Error   *Error      `json:"error,omitempty"`
 }
@@ -33,6 +44,12 @@ type Error struct {
 	Message string `json:"message"`
 }
 
+type Notification struct {
+	JSONRPC string      `json:"jsonrpc"`
+	Method  string      `json:"method"`
+	Params  interface{} `json:"params,omitempty"`
+}
+
 // MCP protocol structures
 type InitializeParams struct {
 	ProtocolVersion string `json:"protocolVersion"`
@@ -44,27 +61,23 @@ type InitializeResult struct {
 	Capabilities    map[string]interface{} `json:"capabilities"`
 }
 
-type ToolDefinition struct {
-	Name         string      `json:"name"`
-	Abstract     string      `json:"abstract"`
-	InputSchema  interface{} `json:"inputSchema"`
-	OutputSchema interface{} `json:"outputSchema,omitempty"`
-}
-
 type ToolsListResult struct {
-	Tools []ToolDefinition `json:"tools"`
+	Tools []commands.ToolDefinition `json:"tools"`
 }
 
 type PromptDefinition struct {
-	Name      string           `json:"name"`
-	Abstract  string           `json:"abstract,omitempty"`
-	Arguments []PromptArgument `json:"arguments,omitempty"`
+	Name        string           `json:"name"`
+	Title       string           `json:"title,omitempty"`
+	Description string           `json:"description,omitempty"`
+	Arguments   []PromptArgument `json:"arguments,omitempty"`
 }
 
 type PromptArgument struct {
-	Name     string `json:"name"`
-	Abstract string `json:"abstract,omitempty"`
-	Required bool   `json:"required,omitempty"`
+	Name        string `json:"name"`
+	Description string `json:"description,omitempty"`
+	Required    bool   `json:"required,omitempty"`
+
+	complete commands.Completer // Answers completion/complete for the argument
 }
 
 type PromptsListResult struct {
@@ -77,7 +90,7 @@ type PromptGetParams struct {
 }
 
 type PromptMessage struct {
-	Role    string          `json:"role"`
+	Role    string           `json:"role"`
 	Content commands.Content `json:"content"`
 }
 
@@ -90,97 +103,803 @@ type ToolCallParams struct {
 	Arguments *json.RawMessage `json:"arguments,omitempty"`
 }
 
-var globalConfig *commands.Config
+type CancelledParams struct {
+	RequestID json.RawMessage `json:"requestId"`
+	Reason    string          `json:"reason,omitempty"`
+}
+
+type SetLevelParams struct {
+	Level string `json:"level"`
+}
+
+type LogMessageParams struct {
+	Level  string      `json:"level"`
+	Logger string      `json:"logger,omitempty"`
+	Data   interface{} `json:"data"`
+}
+
+var globalConfig *commands.ConfigStore
+
+// Syslog severities used by MCP logging, least severe first
+var logLevels = []string{"debug", "info", "notice", "warning", "error", "critical", "alert", "emergency"}
+
+// logLevel is the minimum level sent to the client, set with logging/setLevel
+var (
+	logMu    sync.Mutex
+	logLevel = "warning"
+)
+
+// requestScope tells handlers which client a request came from: the HTTP
+// session (empty for stdio) and how to send it notifications.
+type requestScope struct {
+	session string
+	notify  func(n *Notification)
+}
+
+type scopeKey struct{}
+
+func withScope(ctx context.Context, scope *requestScope) context.Context {
+	return context.WithValue(ctx, scopeKey{}, scope)
+}
+
+func scopeFrom(ctx context.Context) *requestScope {
+	if scope, ok := ctx.Value(scopeKey{}).(*requestScope); ok {
+		return scope
+	}
+	return &requestScope{notify: func(n *Notification) {}}
+}
+
+// inflight tracks the cancel functions of running requests so that
+// notifications/cancelled can stop them.
+type inflight struct {
+	mu      sync.Mutex
+	cancels map[string]*context.CancelFunc
+}
+
+var requests = &inflight{cancels: make(map[string]*context.CancelFunc)}
+
+// requestKey identifies a request by client and id, e.g. "abc123 7".
+func requestKey(session string, id json.RawMessage) string {
+	var buf bytes.Buffer
+	if err := json.Compact(&buf, id); err != nil {
+		return session + " " + string(id)
+	}
+	return session + " " + buf.String()
+}
+
+// start registers a request and returns its context along with a function
+// that must be called when it is done.
+func (f *inflight) start(ctx context.Context, id json.RawMessage) (context.Context, func()) {
+	ctx, cancel := context.WithCancel(ctx)
+	key := requestKey(scopeFrom(ctx).session, id)
+	entry := &cancel
+
+	f.mu.Lock()
+	f.cancels[key] = entry
+	f.mu.Unlock()
+
+	return ctx, func() {
+		f.mu.Lock()
+		if f.cancels[key] == entry {
+			delete(f.cancels, key)
+		}
+		f.mu.Unlock()
+		cancel()
+	}
+}
+
+// cancel stops a running request. Unknown or finished requests are ignored.
+func (f *inflight) cancel(session string, id json.RawMessage) {
+	f.mu.Lock()
+	entry, ok := f.cancels[requestKey(session, id)]
+	f.mu.Unlock()
+	if ok {
+		(*entry)()
+	}
+}
 
 func main() {
+	var showVersion bool
+	flag.BoolVar(&showVersion, "version", false, "Print the version and exit")
+	flag.BoolVar(&showVersion, "v", false, "Print the version and exit")
+	httpAddr := flag.String("http", "", "Serve MCP over streamable HTTP on this address (e.g. 127.0.0.1:8765) instead of stdio")
+	token := flag.String("token", os.Getenv("BCKT_MCP_TOKEN"), "Bearer token required by the HTTP transport (default $BCKT_MCP_TOKEN)")
+	flag.Parse()
+
 	// Check for version flag
-	if len(os.Args) > 1 && (os.Args[1] == "--version" || os.Args[1] == "-v") {
+	if showVersion {
 		fmt.Printf("bckt-mcp version %s\n", version)
 		os.Exit(0)
 	}
 
 	// Load global config on startup
-	globalConfig = commands.LoadGlobalConfig()
+	globalConfig = commands.NewConfigStore(commands.LoadGlobalConfig())
+
+	switch flag.Arg(0) {
+	case "":
+	case "publish-due":
+		os.Exit(publishDue(flag.Args()[1:]))
+	default:
+		fmt.Fprintf(os.Stderr, "Unknown command: %s\n", flag.Arg(0))
+		os.Exit(2)
+	}
+
+	if *httpAddr != "" {
+		if err := serveHTTP(*httpAddr, *token); err != nil {
+			fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+			os.Exit(1)
+		}
+		return
+	}
+
+	serveStdio(newStdioTransport(os.Stdin, os.Stdout))
+}
 
-	reader := bufio.NewReader(os.Stdin)
-	writer := bufio.NewWriter(os.Stdout)
-	defer writer.Flush()
+// publishDue runs bckt_publish_due once, e.g. from cron, and returns the
+// exit code: 1 when a due post could not be moved.
+func publishDue(args []string) int {
+	fs := flag.NewFlagSet("publish-due", flag.ExitOnError)
+	preview := fs.Bool("preview", false, "List the posts that are due without moving them")
+	fs.Parse(args)
+
+	output, err := commands.PublishDue(context.Background(), globalConfig.Get(), time.Now(), *preview)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		return 1
+	}
+
+	fmt.Print(commands.FormatPublishDue(output))
+	if len(output.Failed) > 0 {
+		return 1
+	}
+	return 0
+}
+
+// serveStdio handles every message in its own goroutine, so a slow tool
+// does not hold up the ones after it, while responses are still written in
+// the order the requests arrived.
+func serveStdio(transport *stdioTransport) {
+	ctx := withScope(context.Background(), &requestScope{
+		notify: func(n *Notification) { transport.writeMessage(n) },
+	})
+
+	// Each message gets a slot in the queue, filled when it is handled
+	queue := make(chan chan interface{}, 64)
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		for slot := range queue {
+			// Write response (skip if nil for notifications)
+			if response := <-slot; response != nil {
+				if err := transport.writeMessage(response); err != nil {
+					return
+				}
+			}
+		}
+	}()
 
 	for {
 		// Read incoming message
-		request, err := readMessage(reader)
-		if err != nil {
-			if err == io.EOF {
-				return
-			}
+		data, err := transport.readMessage()
+
+		// Framing problems only affect this message, I/O errors end the loop
+		ferr, isFraming := err.(*framingError)
+		if err != nil && !isFraming {
+			break
+		}
+
+		slot := make(chan interface{}, 1)
+		select {
+		case queue <- slot:
+		case <-done:
+			return // Output is gone
+		}
+
+		if isFraming {
+			slot <- &Response{JSONRPC: "2.0", Error: &Error{Code: ferr.code, Message: ferr.message}}
 			continue
 		}
+		go func() { slot <- handleMessage(ctx, data) }()
+	}
+
+	// Finish answering what is in flight before exiting
+	close(queue)
+	<-done
+}
 
-		// Handle request
-		response := handleRequest(request)
+// handleMessage decodes a JSON-RPC message or batch and handles it. It
+// returns a *Response, a []*Response for batches, or nil when there is
+// nothing to send back.
+func handleMessage(ctx context.Context, data []byte) interface{} {
+	if !json.Valid(data) {
+		return &Response{JSONRPC: "2.0", Error: &Error{Code: -32700, Message: "Parse error"}}
+	}
+
+	trimmed := bytes.TrimSpace(data)
+	if len(trimmed) == 0 || trimmed[0] != '[' {
+		if response := handleSingle(ctx, trimmed); response != nil {
+			return response
+		}
+		return nil
+	}
 
-		// Write response (skip if nil for notifications)
+	var batch []json.RawMessage
+	if err := json.Unmarshal(trimmed, &batch); err != nil || len(batch) == 0 {
+		return &Response{JSONRPC: "2.0", Error: &Error{Code: -32600, Message: "Invalid Request"}}
+	}
+
+	// Handle the batch concurrently, answering in the original order
+	results := make([]*Response, len(batch))
+	var wg sync.WaitGroup
+	for i, item := range batch {
+		wg.Add(1)
+		go func(i int, item json.RawMessage) {
+			defer wg.Done()
+			results[i] = handleSingle(ctx, item)
+		}(i, item)
+	}
+	wg.Wait()
+
+	var responses []*Response
+	for _, response := range results {
 		if response != nil {
-			if err := writeMessage(writer, response); err != nil {
-				return
-			}
+			responses = append(responses, response)
+		}
+	}
+
+	// A batch of notifications gets no response at all
+	if len(responses) == 0 {
+		return nil
+	}
+	return responses
+}
+
+// handleSingle handles one JSON-RPC object.
+func handleSingle(ctx context.Context, data []byte) *Response {
+	var req Request
+	if err := json.Unmarshal(data, &req); err != nil || req.JSONRPC != "2.0" || req.Method == "" {
+		// Echo the id back when we can find one
+		var probe struct {
+			ID json.RawMessage `json:"id"`
 		}
+		json.Unmarshal(data, &probe)
+		return &Response{JSONRPC: "2.0", ID: probe.ID, Error: &Error{Code: -32600, Message: "Invalid Request"}}
 	}
+	return handleRequest(ctx, &req)
 }
 
-func readMessage(r *bufio.Reader) (*Request, error) {
-	// Claude's MCP client uses newline-delimited JSON, not Content-Length headers
-	line, err := r.ReadString('\n')
+// Stdio transport
+
+const maxMessageSize = 10 << 20
+
+// framingError is a problem with a single message that the server can
+// report and recover from.
+type framingError struct {
+	code    int
+	message string
+}
+
+func (e *framingError) Error() string {
+	return e.message
+}
+
+// stdioTransport reads messages either as newline-delimited JSON or with
+// LSP-style Content-Length headers, detected per message, and answers in
+// the framing the client last used.
+type stdioTransport struct {
+	r *bufio.Reader
+	w *bufio.Writer
+
+	mu      sync.Mutex // Guards w and headers, written from many goroutines
+	headers bool       // Last message used Content-Length framing
+}
+
+func newStdioTransport(r io.Reader, w io.Writer) *stdioTransport {
+	return &stdioTransport{
+		r: bufio.NewReader(r),
+		w: bufio.NewWriter(w),
+	}
+}
+
+func (t *stdioTransport) readMessage() ([]byte, error) {
+	// Skip blank lines and whitespace between messages
+	for {
+		b, err := t.r.ReadByte()
+		if err != nil {
+			return nil, err
+		}
+		if b != ' ' && b != '\t' && b != '\r' && b != '\n' {
+			t.r.UnreadByte()
+			break
+		}
+	}
+
+	first, err := t.r.Peek(1)
 	if err != nil {
 		return nil, err
 	}
 
-	line = strings.TrimSpace(line)
-	if line == "" {
-		return nil, io.EOF
+	// JSON starts with an object or array, anything else is a header
+	if first[0] == '{' || first[0] == '[' {
+		t.setHeaders(false)
+		return t.readLine()
 	}
 
-	// Parse request
-	var req Request
-	if err := json.Unmarshal([]byte(line), &req); err != nil {
+	t.setHeaders(true)
+	length := -1
+	for {
+		line, err := t.readLine()
+		if err != nil {
+			return nil, err
+		}
+		line = bytes.TrimRight(line, "\r\n")
+		if len(line) == 0 {
+			break
+		}
+
+		name, value, ok := strings.Cut(string(line), ":")
+		if !ok {
+			return nil, &framingError{code: -32700, message: fmt.Sprintf("Parse error: invalid header %q", line)}
+		}
+		if strings.EqualFold(strings.TrimSpace(name), "Content-Length") {
+			length, err = strconv.Atoi(strings.TrimSpace(value))
+			if err != nil || length < 0 {
+				return nil, &framingError{code: -32700, message: fmt.Sprintf("Parse error: invalid Content-Length %q", value)}
+			}
+		}
+	}
+
+	if length < 0 {
+		return nil, &framingError{code: -32700, message: "Parse error: missing Content-Length header"}
+	}
+	if length > maxMessageSize {
+		if _, err := io.CopyN(io.Discard, t.r, int64(length)); err != nil {
+			return nil, err
+		}
+		return nil, &framingError{code: -32600, message: fmt.Sprintf("Invalid Request: message exceeds %d bytes", maxMessageSize)}
+	}
+
+	data := make([]byte, length)
+	if _, err := io.ReadFull(t.r, data); err != nil {
 		return nil, err
 	}
+	return data, nil
+}
 
-	return &req, nil
+func (t *stdioTransport) setHeaders(headers bool) {
+	t.mu.Lock()
+	t.headers = headers
+	t.mu.Unlock()
 }
 
-func writeMessage(w *bufio.Writer, response *Response) error {
-	data, err := json.Marshal(response)
+// readLine reads up to and including the next newline, discarding lines
+// longer than maxMessageSize.
+func (t *stdioTransport) readLine() ([]byte, error) {
+	var line []byte
+	for {
+		chunk, err := t.r.ReadSlice('\n')
+		if len(line)+len(chunk) > maxMessageSize {
+			for err == bufio.ErrBufferFull {
+				_, err = t.r.ReadSlice('\n')
+			}
+			return nil, &framingError{code: -32600, message: fmt.Sprintf("Invalid Request: message exceeds %d bytes", maxMessageSize)}
+		}
+		line = append(line, chunk...)
+
+		switch {
+		case err == bufio.ErrBufferFull:
+			continue
+		case err == io.EOF && len(line) > 0:
+			return line, nil
+		case err != nil:
+			return nil, err
+		}
+		return line, nil
+	}
+}
+
+// writeMessage writes a response, batch of responses or notification.
+func (t *stdioTransport) writeMessage(message interface{}) error {
+	data, err := json.Marshal(message)
 	if err != nil {
 		return err
 	}
 
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	if t.headers {
+		if _, err := fmt.Fprintf(t.w, "Content-Length: %d\r\n\r\n", len(data)); err != nil {
+			return err
+		}
+		if _, err := t.w.Write(data); err != nil {
+			return err
+		}
+		return t.w.Flush()
+	}
+
 	// Write newline-delimited JSON
-	if _, err := w.Write(data); err != nil {
+	if _, err := t.w.Write(data); err != nil {
 		return err
 	}
 
-	if _, err := w.WriteString("\n"); err != nil {
+	if _, err := t.w.WriteString("\n"); err != nil {
 		return err
 	}
 
-	return w.Flush()
+	return t.w.Flush()
+}
+
+// HTTP transport (MCP streamable HTTP)
+
+const (
+	mcpEndpoint   = "/mcp"
+	sessionHeader = "Mcp-Session-Id"
+
+	// Sessions of clients that went away without a DELETE are dropped
+	// once they have been idle this long
+	sessionIdleTimeout = 30 * time.Minute
+)
+
+type httpSession struct {
+	id     string
+	events chan []byte // Server messages for the SSE stream
+
+	mu       sync.Mutex
+	closed   bool
+	active   int       // Requests and SSE streams in progress
+	lastSeen time.Time // When the session was last looked up or released
+}
+
+// use marks the session busy until the returned function is called.
+func (s *httpSession) use() func() {
+	s.mu.Lock()
+	s.active++
+	s.mu.Unlock()
+
+	return func() {
+		s.mu.Lock()
+		s.active--
+		s.lastSeen = time.Now()
+		s.mu.Unlock()
+	}
+}
+
+// idle reports whether nothing has used the session for sessionIdleTimeout.
+func (s *httpSession) idle(now time.Time) bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.active == 0 && now.Sub(s.lastSeen) > sessionIdleTimeout
+}
+
+// send queues a message for the SSE stream, dropping it when nobody is
+// listening and the queue is full.
+func (s *httpSession) send(message interface{}) {
+	data, err := json.Marshal(message)
+	if err != nil {
+		return
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.closed {
+		return
+	}
+	select {
+	case s.events <- data:
+	default:
+	}
+}
+
+func (s *httpSession) close() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if !s.closed {
+		s.closed = true
+		close(s.events)
+	}
+}
+
+type httpServer struct {
+	token string
+
+	mu       sync.Mutex
+	sessions map[string]*httpSession
+}
+
+func serveHTTP(addr, token string) error {
+	srv := &httpServer{
+		token:    token,
+		sessions: make(map[string]*httpSession),
+	}
+
+	go func() {
+		for now := range time.Tick(time.Minute) {
+			srv.dropIdleSessions(now)
+		}
+	}()
+
+	mux := http.NewServeMux()
+	mux.HandleFunc(mcpEndpoint, srv.handleMCP)
+
+	if token == "" {
+		fmt.Fprintf(os.Stderr, "Warning: no --token set, the HTTP endpoint is unauthenticated\n")
+	}
+	fmt.Fprintf(os.Stderr, "Serving MCP on http://%s%s\n", addr, mcpEndpoint)
+
+	return http.ListenAndServe(addr, mux)
+}
+
+func (s *httpServer) handleMCP(w http.ResponseWriter, r *http.Request) {
+	if !s.authorized(r) {
+		w.Header().Set("WWW-Authenticate", `Bearer realm="bckt-mcp"`)
+		http.Error(w, "Unauthorized", http.StatusUnauthorized)
+		return
+	}
+	if !sameOrigin(r) {
+		http.Error(w, "Forbidden origin", http.StatusForbidden)
+		return
+	}
+
+	switch r.Method {
+	case http.MethodPost:
+		s.handlePost(w, r)
+	case http.MethodGet:
+		s.handleStream(w, r)
+	case http.MethodDelete:
+		session, ok := s.session(w, r)
+		if !ok {
+			return
+		}
+		s.mu.Lock()
+		delete(s.sessions, session.id)
+		s.mu.Unlock()
+		session.close()
+		w.WriteHeader(http.StatusNoContent)
+	default:
+		w.Header().Set("Allow", "GET, POST, DELETE")
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+	}
+}
+
+func (s *httpServer) handlePost(w http.ResponseWriter, r *http.Request) {
+	var ok bool
+	body, err := io.ReadAll(http.MaxBytesReader(w, r.Body, maxMessageSize))
+	if err != nil {
+		http.Error(w, "Request body too large", http.StatusRequestEntityTooLarge)
+		return
+	}
+
+	if !json.Valid(body) {
+		writeHTTPResponse(w, r, http.StatusBadRequest, handleMessage(r.Context(), body))
+		return
+	}
+
+	// initialize starts a new session, everything else must belong to one
+	var probe Request
+	var session *httpSession
+	if json.Unmarshal(body, &probe) == nil && probe.Method == "initialize" {
+		session, err = s.newSession()
+		if err != nil {
+			http.Error(w, "Failed to create session", http.StatusInternalServerError)
+			return
+		}
+		w.Header().Set(sessionHeader, session.id)
+	} else if session, ok = s.session(w, r); !ok {
+		return
+	}
+	defer session.use()()
+
+	// Requests are cancelled when the client disconnects
+	ctx := withScope(r.Context(), &requestScope{
+		session: session.id,
+		notify:  func(n *Notification) { session.send(n) },
+	})
+	response := handleMessage(ctx, body)
+
+	// Notifications get no response body
+	if response == nil {
+		w.WriteHeader(http.StatusAccepted)
+		return
+	}
+
+	writeHTTPResponse(w, r, http.StatusOK, response)
+}
+
+// handleStream keeps an SSE stream open for messages the server sends on
+// its own, e.g. notifications.
+func (s *httpServer) handleStream(w http.ResponseWriter, r *http.Request) {
+	if !strings.Contains(r.Header.Get("Accept"), "text/event-stream") {
+		http.Error(w, "Accept must include text/event-stream", http.StatusNotAcceptable)
+		return
+	}
+	session, ok := s.session(w, r)
+	if !ok {
+		return
+	}
+	defer session.use()()
+	flusher, ok := w.(http.Flusher)
+	if !ok {
+		http.Error(w, "Streaming not supported", http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "text/event-stream")
+	w.Header().Set("Cache-Control", "no-cache")
+	w.WriteHeader(http.StatusOK)
+	flusher.Flush()
+
+	keepAlive := time.NewTicker(30 * time.Second)
+	defer keepAlive.Stop()
+
+	for {
+		select {
+		case <-r.Context().Done():
+			return
+		case <-keepAlive.C:
+			fmt.Fprint(w, ": keep-alive\n\n")
+			flusher.Flush()
+		case data, ok := <-session.events:
+			if !ok {
+				return
+			}
+			fmt.Fprintf(w, "event: message\ndata: %s\n\n", data)
+			flusher.Flush()
+		}
+	}
+}
+
+func (s *httpServer) newSession() (*httpSession, error) {
+	buf := make([]byte, 16)
+	if _, err := rand.Read(buf); err != nil {
+		return nil, err
+	}
+
+	session := &httpSession{
+		id:       hex.EncodeToString(buf),
+		events:   make(chan []byte, 64),
+		lastSeen: time.Now(),
+	}
+
+	s.mu.Lock()
+	s.sessions[session.id] = session
+	s.mu.Unlock()
+
+	return session, nil
+}
+
+// session looks up the session of a request, writing an error response when
+// it is missing or unknown.
+func (s *httpServer) session(w http.ResponseWriter, r *http.Request) (*httpSession, bool) {
+	id := r.Header.Get(sessionHeader)
+	if id == "" {
+		http.Error(w, "Missing "+sessionHeader+" header", http.StatusBadRequest)
+		return nil, false
+	}
+
+	s.mu.Lock()
+	session, ok := s.sessions[id]
+	if ok {
+		// Keep the session from being dropped before the caller uses it
+		session.mu.Lock()
+		session.lastSeen = time.Now()
+		session.mu.Unlock()
+	}
+	s.mu.Unlock()
+
+	if !ok {
+		http.Error(w, "Unknown session", http.StatusNotFound)
+		return nil, false
+	}
+	return session, true
+}
+
+// dropIdleSessions closes and forgets the sessions nobody has used for
+// sessionIdleTimeout.
+func (s *httpServer) dropIdleSessions(now time.Time) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for id, session := range s.sessions {
+		if session.idle(now) {
+			delete(s.sessions, id)
+			session.close()
+		}
+	}
+}
+
+func (s *httpServer) authorized(r *http.Request) bool {
+	if s.token == "" {
+		return true
+	}
+	got, ok := strings.CutPrefix(r.Header.Get("Authorization"), "Bearer ")
+	return ok && subtle.ConstantTimeCompare([]byte(got), []byte(s.token)) == 1
+}
+
+// sameOrigin rejects browser requests from other sites (DNS rebinding).
+func sameOrigin(r *http.Request) bool {
+	origin := r.Header.Get("Origin")
+	if origin == "" {
+		return true
+	}
+	u, err := url.Parse(origin)
+	if err != nil {
+		return false
+	}
+	return u.Host == r.Host
+}
+
+// writeHTTPResponse sends a response as JSON, or as a single SSE event when
+// the client only accepts event streams.
+func writeHTTPResponse(w http.ResponseWriter, r *http.Request, status int, response interface{}) {
+	data, err := json.Marshal(response)
+	if err != nil {
+		http.Error(w, "Failed to encode response", http.StatusInternalServerError)
+		return
+	}
+
+	accept := r.Header.Get("Accept")
+	if strings.Contains(accept, "text/event-stream") && !strings.Contains(accept, "application/json") {
+		w.Header().Set("Content-Type", "text/event-stream")
+		w.WriteHeader(status)
+		fmt.Fprintf(w, "event: message\ndata: %s\n\n", data)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	w.Write(data)
+}
+
+func handleRequest(ctx context.Context, req *Request) *Response {
+	// Notifications never get a response, not even an error
+	if len(req.ID) == 0 {
+		dispatch(ctx, req)
+		return nil
+	}
+
+	ctx, done := requests.start(ctx, req.ID)
+	defer done()
+
+	response := dispatch(ctx, req)
+
+	// A cancelled request is not answered, the client has stopped waiting
+	if ctx.Err() != nil {
+		return nil
+	}
+	return response
 }
 
-func handleRequest(req *Request) *Response {
+func dispatch(ctx context.Context, req *Request) *Response {
 	switch req.Method {
 	case "initialize":
 		return handleInitialize(req)
 	case "initialized", "notifications/initialized":
 		return nil // Notification, no response needed
+	case "notifications/cancelled":
+		handleCancelled(ctx, req)
+		return nil
+	case "ping":
+		return &Response{
+			JSONRPC: "2.0",
+			ID:      req.ID,
+			Result:  map[string]interface{}{},
+		}
+	case "logging/setLevel":
+		return handleSetLevel(req)
 	case "tools/list":
 		return handleToolsList(req)
 	case "tools/call":
-		return handleToolCall(req)
+		return handleToolCall(ctx, req)
 	case "prompts/list":
 		return handlePromptsList(req)
 	case "prompts/get":
 		return handlePromptsGet(req)
+	case "resources/list":
+		return convertResponse(commands.HandleResourcesList(ctx, req.ID, globalConfig))
+	case "resources/read":
+		return convertResponse(commands.HandleResourcesRead(ctx, req.ID, req.Params, globalConfig))
+	case "completion/complete":
+		return convertResponse(commands.HandleComplete(ctx, req.ID, req.Params, globalConfig, promptCompleters()))
 	default:
 		return &Response{
 			JSONRPC: "2.0",
@@ -190,6 +909,62 @@ func handleRequest(req *Request) *Response {
 	}
 }
 
+// handleCancelled stops the request named in a notifications/cancelled.
+func handleCancelled(ctx context.Context, req *Request) {
+	var params CancelledParams
+	if err := json.Unmarshal(req.Params, &params); err != nil || len(params.RequestID) == 0 {
+		return
+	}
+	requests.cancel(scopeFrom(ctx).session, params.RequestID)
+}
+
+func handleSetLevel(req *Request) *Response {
+	var params SetLevelParams
+	if err := json.Unmarshal(req.Params, &params); err != nil || logLevelIndex(params.Level) < 0 {
+		return &Response{
+			JSONRPC: "2.0",
+			ID:      req.ID,
+			Error:   &Error{Code: -32602, Message: "Invalid params: level must be one of " + strings.Join(logLevels, ", ")},
+		}
+	}
+
+	logMu.Lock()
+	logLevel = params.Level
+	logMu.Unlock()
+
+	return &Response{
+		JSONRPC: "2.0",
+		ID:      req.ID,
+		Result:  map[string]interface{}{},
+	}
+}
+
+func logLevelIndex(level string) int {
+	for i, l := range logLevels {
+		if l == level {
+			return i
+		}
+	}
+	return -1
+}
+
+// logMessage sends a notifications/message to the client when level is at
+// or above the level it asked for.
+func logMessage(ctx context.Context, level string, data interface{}) {
+	logMu.Lock()
+	minLevel := logLevel
+	logMu.Unlock()
+
+	if logLevelIndex(level) < logLevelIndex(minLevel) {
+		return
+	}
+	scopeFrom(ctx).notify(&Notification{
+		JSONRPC: "2.0",
+		Method:  "notifications/message",
+		Params:  LogMessageParams{Level: level, Logger: "bckt-mcp", Data: data},
+	})
+}
+
 func handleInitialize(req *Request) *Response {
 	var params InitializeParams
 	if len(req.Params) > 0 {
@@ -218,175 +993,25 @@ func handleInitialize(req *Request) *Response {
 				"version": version,
 			},
 			Capabilities: map[string]interface{}{
-				"tools":   map[string]interface{}{},
-				"prompts": map[string]interface{}{},
+				"tools":       map[string]interface{}{},
+				"prompts":     map[string]interface{}{},
+				"resources":   map[string]interface{}{},
+				"logging":     map[string]interface{}{},
+				"completions": map[string]interface{}{},
 			},
 		},
 	}
 }
 
 func handleToolsList(req *Request) *Response {
-	tools := []ToolDefinition{
-		{
-			Name:     "bckt",
-			Abstract: "Format raw text and metadata into bckt-compatible Markdown with YAML front matter and compute file path. IMPORTANT: Before calling this tool, you MUST ask the user to provide: title, tags, abstract, and optionally slug, language, and excerpt. Never auto-generate metadata without explicit user confirmation.",
-			InputSchema: map[string]interface{}{
-				"type": "object",
-				"properties": map[string]interface{}{
-					"raw": map[string]interface{}{
-						"type":     "string",
-						"abstract": "Raw markdown content",
-					},
-					"meta": map[string]interface{}{
-						"type":     "object",
-						"abstract": "Metadata for front matter",
-						"properties": map[string]interface{}{
-							"title":    map[string]interface{}{"type": "string"},
-							"slug":     map[string]interface{}{"type": "string"},
-							"date":     map[string]interface{}{"type": "string"},
-							"tags":     map[string]interface{}{"type": "array", "items": map[string]interface{}{"type": "string"}},
-							"abstract": map[string]interface{}{"type": "string"},
-							"lang":     map[string]interface{}{"type": "string"},
-						},
-						"required": []string{"title"},
-					},
-					"config": map[string]interface{}{
-						"type":     "string",
-						"abstract": "Optional TOML configuration",
-					},
-					"strategy": map[string]interface{}{
-						"type":     "string",
-						"enum":     []string{"strict", "lenient"},
-						"abstract": "Validation strategy",
-					},
-				},
-				"required": []string{"raw", "meta"},
-			},
-			OutputSchema: map[string]interface{}{
-				"type": "object",
-				"properties": map[string]interface{}{
-					"path":     map[string]interface{}{"type": "string"},
-					"markdown": map[string]interface{}{"type": "string"},
-					"warnings": map[string]interface{}{"type": "array", "items": map[string]interface{}{"type": "string"}},
-				},
-			},
-		},
-		{
-			Name:     "bckt_preview",
-			Abstract: "Preview the formatted output without saving. Shows the generated YAML front matter, markdown, and computed file path.",
-			InputSchema: map[string]interface{}{
-				"type": "object",
-				"properties": map[string]interface{}{
-					"raw": map[string]interface{}{
-						"type":     "string",
-						"abstract": "Raw markdown content",
-					},
-					"meta": map[string]interface{}{
-						"type":     "object",
-						"abstract": "Metadata for front matter",
-						"properties": map[string]interface{}{
-							"title":    map[string]interface{}{"type": "string"},
-							"slug":     map[string]interface{}{"type": "string"},
-							"date":     map[string]interface{}{"type": "string"},
-							"tags":     map[string]interface{}{"type": "array", "items": map[string]interface{}{"type": "string"}},
-							"abstract": map[string]interface{}{"type": "string"},
-							"lang":     map[string]interface{}{"type": "string"},
-						},
-						"required": []string{"title"},
-					},
-					"config":   map[string]interface{}{"type": "string", "abstract": "Optional TOML configuration"},
-					"strategy": map[string]interface{}{"type": "string", "enum": []string{"strict", "lenient"}, "abstract": "Validation strategy"},
-				},
-				"required": []string{"raw", "meta"},
-			},
-		},
-		{
-			Name:     "bckt_save",
-			Abstract: "Save the formatted markdown to the computed file path. Creates directories if needed. On first use, asks for root_path (e.g., /Users/yourname/blog) and saves it to config.",
-			InputSchema: map[string]interface{}{
-				"type": "object",
-				"properties": map[string]interface{}{
-					"markdown": map[string]interface{}{
-						"type":     "string",
-						"abstract": "The complete formatted markdown with front matter",
-					},
-					"path": map[string]interface{}{
-						"type":     "string",
-						"abstract": "The file path where to save (from bckt or bckt_preview output)",
-					},
-					"root_path": map[string]interface{}{
-						"type":     "string",
-						"abstract": "Root directory for blog posts (required on first save, then saved to config)",
-					},
-				},
-				"required": []string{"markdown", "path"},
-			},
-		},
-		{
-			Name:     "bckt_config",
-			Abstract: "View or update the bckt-mcp configuration. If no parameters provided, returns current config. If parameters provided, updates config and saves it.",
-			InputSchema: map[string]interface{}{
-				"type": "object",
-				"properties": map[string]interface{}{
-					"root_path": map[string]interface{}{
-						"type":     "string",
-						"abstract": "Root directory for blog posts",
-					},
-					"timezone": map[string]interface{}{
-						"type":     "string",
-						"abstract": "Timezone for dates (e.g., 'America/New_York', 'Europe/London', 'UTC')",
-					},
-					"path_pattern": map[string]interface{}{
-						"type":     "string",
-						"abstract": "Path pattern with placeholders: {yyyy}, {MM}, {DD}, {slug}",
-					},
-					"wrap_at": map[string]interface{}{
-						"type":     "integer",
-						"abstract": "Line width for text wrapping",
-					},
-				},
-			},
-		},
-		{
-			Name:     "bckt_setup",
-			Abstract: "Interactive setup wizard for first-time configuration. Shows current values and suggestions, then saves all settings at once when confirmed.",
-			InputSchema: map[string]interface{}{
-				"type": "object",
-				"properties": map[string]interface{}{
-					"root_path": map[string]interface{}{
-						"type":     "string",
-						"abstract": "Root directory for blog posts",
-					},
-					"timezone": map[string]interface{}{
-						"type":     "string",
-						"abstract": "Timezone for dates",
-					},
-					"path_pattern": map[string]interface{}{
-						"type":     "string",
-						"abstract": "Path pattern (optional, uses default if not provided)",
-					},
-					"wrap_at": map[string]interface{}{
-						"type":     "integer",
-						"abstract": "Line width for text wrapping (optional, uses default if not provided)",
-					},
-					"confirm": map[string]interface{}{
-						"type":     "boolean",
-						"abstract": "Set to true to save the configuration",
-					},
-				},
-				"required": []string{"root_path", "timezone"},
-			},
-		},
-	}
-
 	return &Response{
 		JSONRPC: "2.0",
 		ID:      req.ID,
-		Result:  ToolsListResult{Tools: tools},
+		Result:  ToolsListResult{Tools: commands.ToolDefinitions()},
 	}
 }
 
-func handleToolCall(req *Request) *Response {
+func handleToolCall(ctx context.Context, req *Request) (response *Response) {
 	var params ToolCallParams
 	if err := json.Unmarshal(req.Params, &params); err != nil {
 		return &Response{
@@ -396,48 +1021,100 @@ func handleToolCall(req *Request) *Response {
 		}
 	}
 
-	cmdParams := commands.ToolCallParams{
-		Name:      params.Name,
-		Arguments: params.Arguments,
+	logMessage(ctx, "debug", fmt.Sprintf("Calling tool %s", params.Name))
+	defer func() {
+		if response != nil && response.Error != nil {
+			logMessage(ctx, "error", fmt.Sprintf("Tool %s failed: %s", params.Name, response.Error.Message))
+		}
+	}()
+
+	// Give up on read-only tools that run past their timeout. Tools that
+	// scan posts stop early; others finish in the background.
+	timeout := globalConfig.Get().ToolTimeout(params.Name)
+	if timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, timeout)
+		defer cancel()
 	}
 
-	switch params.Name {
-	case "bckt", "bckt_preview":
-		cmdResp := commands.HandleBcktFormat(req.ID, cmdParams, params.Name == "bckt_preview", globalConfig)
-		return convertResponse(cmdResp)
-	case "bckt_save":
-		cmdResp := commands.HandleBcktSave(req.ID, cmdParams, globalConfig)
-		return convertResponse(cmdResp)
-	case "bckt_config":
-		cmdResp := commands.HandleBcktConfig(req.ID, cmdParams, globalConfig)
-		return convertResponse(cmdResp)
-	case "bckt_setup":
-		cmdResp := commands.HandleBcktSetup(req.ID, cmdParams, &globalConfig)
-		return convertResponse(cmdResp)
-	default:
-		return &Response{
-			JSONRPC: "2.0",
-			ID:      req.ID,
-			Error:   &Error{Code: -32601, Message: "Unknown tool"},
+	// Write tools are waited for instead: they stop before writing once ctx
+	// is done and otherwise finish, so a timeout is never reported for a
+	// write that still happens
+	if !commands.ReadOnlyTool(params.Name) {
+		response = callTool(ctx, req.ID, params)
+		if ctx.Err() == context.Canceled {
+			return nil // Cancelled, nobody is waiting for the result
+		}
+		return response
+	}
+
+	result := make(chan *Response, 1)
+	go func() { result <- callTool(ctx, req.ID, params) }()
+
+	select {
+	case response = <-result:
+		return response
+	case <-ctx.Done():
+		if ctx.Err() == context.DeadlineExceeded {
+			return &Response{
+				JSONRPC: "2.0",
+				ID:      req.ID,
+				Error:   &Error{Code: 1, Message: fmt.Sprintf("Tool %s timed out after %s", params.Name, timeout)},
+			}
 		}
+		return nil // Cancelled, nobody is waiting for the result
 	}
 }
 
-func handlePromptsList(req *Request) *Response {
-	prompts := []PromptDefinition{
-		{
-			Name:     "format_blog_post",
-			Abstract: "Interactive workflow to format a blog post with user input for metadata",
-			Arguments: []PromptArgument{
-				{
-					Name:     "content",
-					Abstract: "The raw blog post content",
-					Required: true,
-				},
+func callTool(ctx context.Context, id json.RawMessage, params ToolCallParams) *Response {
+	cmdParams := commands.ToolCallParams{
+		Name:      params.Name,
+		Arguments: params.Arguments,
+	}
+	return convertResponse(commands.CallTool(ctx, id, cmdParams, globalConfig))
+}
+
+var prompts = []PromptDefinition{
+	{
+		Name:        "format_blog_post",
+		Title:       "Format Blog Post",
+		Description: "Interactive workflow to format a blog post with user input for metadata",
+		Arguments: []PromptArgument{
+			{
+				Name:        "content",
+				Description: "The raw blog post content",
+				Required:    true,
+			},
+			{
+				Name:        "tags",
+				Description: "Comma-separated tags, if already chosen",
+				complete:    commands.CompleteTagList,
+			},
+			{
+				Name:        "lang",
+				Description: "Language code, if already chosen",
+				complete:    commands.CompleteLangs,
 			},
 		},
+	},
+}
+
+// promptCompleters maps each prompt to the completers of its arguments.
+func promptCompleters() map[string]map[string]commands.Completer {
+	completers := make(map[string]map[string]commands.Completer, len(prompts))
+	for _, prompt := range prompts {
+		arguments := map[string]commands.Completer{}
+		for _, argument := range prompt.Arguments {
+			if argument.complete != nil {
+				arguments[argument.Name] = argument.complete
+			}
+		}
+		completers[prompt.Name] = arguments
 	}
+	return completers
+}
 
+func handlePromptsList(req *Request) *Response {
 	return &Response{
 		JSONRPC: "2.0",
 		ID:      req.ID,
@@ -484,16 +1161,13 @@ func handlePromptsGet(req *Request) *Response {
 		}
 	}
 
-	content := ""
-	if params.Arguments != nil {
-		if c, ok := params.Arguments["content"].(string); ok {
-			content = c
-		}
-	}
+	content, _ := params.Arguments["content"].(string)
+	tags, _ := params.Arguments["tags"].(string)
+	lang, _ := params.Arguments["lang"].(string)
 
 	instructions := `You are helping the user format a blog post for their bckt static site.
 
-IMPORTANT: Your FIRST action must be to call the bckt_config tool (with no parameters) to check current configuration.
+IMPORTANT: Your FIRST action must be to call the bckt_config_view tool to check current configuration.
 
 If root_path is empty or not set in the config, you MUST guide the user through bckt_setup:
 1. Call bckt_setup with only root_path and timezone to show a preview
@@ -504,7 +1178,7 @@ DO NOT assume or make up default values. ALWAYS use bckt_setup to show the real
 
 Once configuration is confirmed, follow these steps to format the blog post:
 1. Ask the user for the blog post title
-2. Ask for tags (comma-separated list)
+2. Ask for tags (comma-separated list), preferring tags the blog already uses
 3. Ask for a brief abstract (SEO meta description)
 4. Ask if they want to specify a custom slug (or auto-generate from title)
 5. Ask for the language code (default: en)
@@ -514,9 +1188,20 @@ For each step, provide a suggested value based on the content.
 Once you have all the information, use the bckt or bckt_preview tool with:
 - raw: the content provided below
 - meta: object with title, tags (array), abstract, slug (optional), lang
+`
+
+	// Metadata picked while invoking the prompt doesn't need to be asked for
+	if tags != "" || lang != "" {
+		instructions += "\nThe user already chose (use these, don't ask again):\n"
+		if tags != "" {
+			instructions += "- tags: " + tags + "\n"
+		}
+		if lang != "" {
+			instructions += "- lang: " + lang + "\n"
+		}
+	}
 
-Content to format:
-` + content
+	instructions += "\nContent to format:\n" + content
 
 	messages := []PromptMessage{
 		{
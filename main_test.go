@@ -0,0 +1,428 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"bckt-mcp/commands"
+)
+
+func TestReadMessage(t *testing.T) {
+	big := strings.Repeat("x", maxMessageSize+1)
+
+	tests := []struct {
+		name    string
+		input   string
+		want    []string // Messages, or "error CODE" for framing errors
+		headers []bool   // The framing replies use after each read
+		wantErr error    // How reading ends
+	}{
+		{
+			name:    "newline delimited",
+			input:   "{\"a\":1}\n[{\"b\":2}]\n",
+			want:    []string{"{\"a\":1}\n", "[{\"b\":2}]\n"},
+			headers: []bool{false, false},
+			wantErr: io.EOF,
+		},
+		{
+			name:    "blank lines and no final newline",
+			input:   "\n\r\n  \t{\"a\":1}\r\n\n{\"b\":2}",
+			want:    []string{"{\"a\":1}\r\n", "{\"b\":2}"},
+			headers: []bool{false, false},
+			wantErr: io.EOF,
+		},
+		{
+			name:    "content length",
+			input:   "Content-Length: 7\r\n\r\n{\"a\":1}Content-Length: 2\r\n\r\n{}",
+			want:    []string{"{\"a\":1}", "{}"},
+			headers: []bool{true, true},
+			wantErr: io.EOF,
+		},
+		{
+			name:    "headers in any case, plain newlines and other headers",
+			input:   "content-length: 2\nContent-Type: application/vscode-jsonrpc; charset=utf-8\n\n{}",
+			want:    []string{"{}"},
+			headers: []bool{true},
+			wantErr: io.EOF,
+		},
+		{
+			name:    "body spanning lines",
+			input:   "Content-Length: 10\r\n\r\n{\n\"a\": 1\n}",
+			want:    []string{"{\n\"a\": 1\n}"},
+			headers: []bool{true},
+			wantErr: io.EOF,
+		},
+		{
+			name:    "mixed framing",
+			input:   "Content-Length: 2\r\n\r\n{}\n{\"a\":1}\nContent-Length: 2\r\n\r\n[]",
+			want:    []string{"{}", "{\"a\":1}\n", "[]"},
+			headers: []bool{true, false, true},
+			wantErr: io.EOF,
+		},
+		{
+			name:    "missing content length",
+			input:   "Content-Type: application/json\r\n\r\n{}\n",
+			want:    []string{"error -32700", "{}\n"},
+			headers: []bool{true, false},
+			wantErr: io.EOF,
+		},
+		{
+			name:    "invalid header",
+			input:   "hello\n{}\n",
+			want:    []string{"error -32700", "{}\n"},
+			headers: []bool{true, false},
+			wantErr: io.EOF,
+		},
+		{
+			name:    "invalid content length",
+			input:   "Content-Length: -1\r\n\r\n{}\n",
+			want:    []string{"error -32700", "{}\n"},
+			headers: []bool{true, false},
+			wantErr: io.EOF,
+		},
+		{
+			name:    "truncated body",
+			input:   "Content-Length: 10\r\n\r\n{}",
+			headers: []bool{},
+			wantErr: io.ErrUnexpectedEOF,
+		},
+		{
+			name:    "oversized body is skipped",
+			input:   fmt.Sprintf("Content-Length: %d\r\n\r\n%s{}\n", len(big), big),
+			want:    []string{"error -32600", "{}\n"},
+			headers: []bool{true, false},
+			wantErr: io.EOF,
+		},
+		{
+			name:    "oversized line is skipped",
+			input:   "{\"a\":\"" + big + "\"}\n{}\n",
+			want:    []string{"error -32600", "{}\n"},
+			headers: []bool{false, false},
+			wantErr: io.EOF,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			transport := newStdioTransport(strings.NewReader(tt.input), io.Discard)
+
+			var got []string
+			headers := []bool{}
+			var err error
+			for {
+				var data []byte
+				data, err = transport.readMessage()
+				if ferr, ok := err.(*framingError); ok {
+					got = append(got, fmt.Sprintf("error %d", ferr.code))
+				} else if err != nil {
+					break
+				} else {
+					got = append(got, string(data))
+				}
+				headers = append(headers, transport.headers)
+			}
+
+			if err != tt.wantErr {
+				t.Errorf("reading ended with %v, want %v", err, tt.wantErr)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("messages = %.200q, want %.200q", got, tt.want)
+			}
+			if !reflect.DeepEqual(headers, tt.headers) {
+				t.Errorf("headers = %v, want %v", headers, tt.headers)
+			}
+		})
+	}
+}
+
+func TestWriteMessage(t *testing.T) {
+	tests := []struct {
+		name    string
+		headers bool
+		want    string
+	}{
+		{"newline delimited", false, "{\"jsonrpc\":\"2.0\",\"id\":1,\"result\":{}}\n"},
+		{"content length", true, "Content-Length: 36\r\n\r\n{\"jsonrpc\":\"2.0\",\"id\":1,\"result\":{}}"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var out bytes.Buffer
+			transport := newStdioTransport(strings.NewReader(""), &out)
+			transport.headers = tt.headers
+
+			if err := transport.writeMessage(&Response{JSONRPC: "2.0", ID: 1, Result: struct{}{}}); err != nil {
+				t.Fatal(err)
+			}
+			if out.String() != tt.want {
+				t.Errorf("wrote %q, want %q", out.String(), tt.want)
+			}
+		})
+	}
+}
+
+// newTestHTTPServer serves the MCP endpoint with the given token.
+func newTestHTTPServer(t *testing.T, token string) (*httpServer, *httptest.Server) {
+	t.Helper()
+	t.Setenv("HOME", t.TempDir())
+	globalConfig = commands.NewConfigStore(nil)
+
+	srv := &httpServer{token: token, sessions: make(map[string]*httpSession)}
+	ts := httptest.NewServer(http.HandlerFunc(srv.handleMCP))
+	t.Cleanup(ts.Close)
+	return srv, ts
+}
+
+// httpCall sends a request to the MCP endpoint; headers are given as
+// name, value pairs.
+func httpCall(t *testing.T, ts *httptest.Server, method, body string, headers ...string) *http.Response {
+	t.Helper()
+	req, err := http.NewRequest(method, ts.URL, strings.NewReader(body))
+	if err != nil {
+		t.Fatal(err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("Accept", "application/json, text/event-stream")
+	for i := 0; i+1 < len(headers); i += 2 {
+		req.Header.Set(headers[i], headers[i+1])
+	}
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { resp.Body.Close() })
+	return resp
+}
+
+const (
+	initializeRequest = `{"jsonrpc":"2.0","id":1,"method":"initialize","params":{"protocolVersion":"2025-06-18"}}`
+	pingRequest       = `{"jsonrpc":"2.0","id":2,"method":"ping"}`
+)
+
+func TestHTTPSessions(t *testing.T) {
+	srv, ts := newTestHTTPServer(t, "")
+
+	resp := httpCall(t, ts, http.MethodPost, initializeRequest)
+	session := resp.Header.Get(sessionHeader)
+	if resp.StatusCode != http.StatusOK || session == "" {
+		t.Fatalf("initialize: status %d, session %q", resp.StatusCode, session)
+	}
+
+	tests := []struct {
+		name    string
+		method  string
+		body    string
+		session string
+		want    int
+	}{
+		{"request in the session", http.MethodPost, pingRequest, session, http.StatusOK},
+		{"notification", http.MethodPost, `{"jsonrpc":"2.0","method":"notifications/initialized"}`, session, http.StatusAccepted},
+		{"missing session", http.MethodPost, pingRequest, "", http.StatusBadRequest},
+		{"unknown session", http.MethodPost, pingRequest, "0123456789abcdef", http.StatusNotFound},
+		{"unsupported method", http.MethodPut, pingRequest, session, http.StatusMethodNotAllowed},
+		{"delete", http.MethodDelete, "", session, http.StatusNoContent},
+		{"request after delete", http.MethodPost, pingRequest, session, http.StatusNotFound},
+		{"delete again", http.MethodDelete, "", session, http.StatusNotFound},
+	}
+
+	for _, tt := range tests {
+		var headers []string
+		if tt.session != "" {
+			headers = []string{sessionHeader, tt.session}
+		}
+		if resp := httpCall(t, ts, tt.method, tt.body, headers...); resp.StatusCode != tt.want {
+			body, _ := io.ReadAll(resp.Body)
+			t.Errorf("%s: status %d, want %d (%s)", tt.name, resp.StatusCode, tt.want, body)
+		}
+	}
+
+	srv.mu.Lock()
+	defer srv.mu.Unlock()
+	if len(srv.sessions) != 0 {
+		t.Errorf("%d sessions left after DELETE", len(srv.sessions))
+	}
+}
+
+func TestHTTPIdleSessions(t *testing.T) {
+	srv, ts := newTestHTTPServer(t, "")
+
+	idle := httpCall(t, ts, http.MethodPost, initializeRequest).Header.Get(sessionHeader)
+	busy := httpCall(t, ts, http.MethodPost, initializeRequest).Header.Get(sessionHeader)
+	recent := httpCall(t, ts, http.MethodPost, initializeRequest).Header.Get(sessionHeader)
+
+	// busy has an SSE stream open, recent was used a moment before the sweep
+	srv.mu.Lock()
+	release := srv.sessions[busy].use()
+	srv.mu.Unlock()
+	defer release()
+
+	later := time.Now().Add(sessionIdleTimeout + time.Minute)
+	srv.mu.Lock()
+	srv.sessions[recent].lastSeen = later.Add(-time.Minute)
+	srv.mu.Unlock()
+	srv.dropIdleSessions(later)
+
+	for _, tt := range []struct {
+		session string
+		want    int
+	}{
+		{idle, http.StatusNotFound},
+		{busy, http.StatusOK},
+		{recent, http.StatusOK},
+	} {
+		if resp := httpCall(t, ts, http.MethodPost, pingRequest, sessionHeader, tt.session); resp.StatusCode != tt.want {
+			t.Errorf("session %s: status %d, want %d", tt.session, resp.StatusCode, tt.want)
+		}
+	}
+}
+
+func TestHTTPAuthorization(t *testing.T) {
+	_, ts := newTestHTTPServer(t, "secret")
+	host := strings.TrimPrefix(ts.URL, "http://")
+
+	tests := []struct {
+		name    string
+		headers []string
+		want    int
+	}{
+		{"no token", nil, http.StatusUnauthorized},
+		{"wrong token", []string{"Authorization", "Bearer nope"}, http.StatusUnauthorized},
+		{"token without Bearer", []string{"Authorization", "secret"}, http.StatusUnauthorized},
+		{"token", []string{"Authorization", "Bearer secret"}, http.StatusOK},
+		{"same origin", []string{"Authorization", "Bearer secret", "Origin", "http://" + host}, http.StatusOK},
+		{"other origin", []string{"Authorization", "Bearer secret", "Origin", "http://evil.example"}, http.StatusForbidden},
+		{"bad origin", []string{"Authorization", "Bearer secret", "Origin", "http://%zz"}, http.StatusForbidden},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			resp := httpCall(t, ts, http.MethodPost, initializeRequest, tt.headers...)
+			if resp.StatusCode != tt.want {
+				t.Errorf("status %d, want %d", resp.StatusCode, tt.want)
+			}
+			if tt.want == http.StatusUnauthorized && resp.Header.Get("WWW-Authenticate") == "" {
+				t.Errorf("401 without WWW-Authenticate")
+			}
+		})
+	}
+}
+
+func TestStdioBatch(t *testing.T) {
+	t.Setenv("HOME", t.TempDir())
+	globalConfig = commands.NewConfigStore(nil)
+
+	tests := []struct {
+		name  string
+		input string
+		want  string
+	}{
+		{
+			name:  "batch with a notification",
+			input: `[{"jsonrpc":"2.0","id":1,"method":"ping"},{"jsonrpc":"2.0","method":"notifications/initialized"},{"jsonrpc":"2.0","id":"b","method":"ping"}]`,
+			want:  `[{"jsonrpc":"2.0","id":1,"result":{}},{"jsonrpc":"2.0","id":"b","result":{}}]`,
+		},
+		{
+			name:  "batch of notifications",
+			input: `[{"jsonrpc":"2.0","method":"notifications/initialized"},{"jsonrpc":"2.0","method":"notifications/cancelled","params":{"requestId":9}}]`,
+			want:  "",
+		},
+		{
+			name:  "invalid member",
+			input: `[{"jsonrpc":"2.0","id":1,"method":"ping"},{"id":2}]`,
+			want:  `[{"jsonrpc":"2.0","id":1,"result":{}},{"jsonrpc":"2.0","id":2,"error":{"code":-32600,"message":"Invalid Request"}}]`,
+		},
+		{
+			name:  "empty batch",
+			input: `[]`,
+			want:  `{"jsonrpc":"2.0","id":null,"error":{"code":-32600,"message":"Invalid Request"}}`,
+		},
+		{
+			name:  "notification",
+			input: `{"jsonrpc":"2.0","method":"notifications/initialized"}`,
+			want:  "",
+		},
+		{
+			name:  "unknown method as notification",
+			input: `{"jsonrpc":"2.0","method":"no/such/method"}`,
+			want:  "",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var out bytes.Buffer
+			serveStdio(newStdioTransport(strings.NewReader(tt.input+"\n"), &out))
+
+			want := tt.want
+			if want != "" {
+				want += "\n"
+			}
+			if out.String() != want {
+				t.Errorf("wrote %q, want %q", out.String(), want)
+			}
+		})
+	}
+}
+
+func TestCancelled(t *testing.T) {
+	t.Setenv("HOME", t.TempDir())
+	globalConfig = commands.NewConfigStore(nil)
+
+	scope := func(session string) context.Context {
+		return withScope(context.Background(), &requestScope{session: session, notify: func(n *Notification) {}})
+	}
+	cancel := func(ctx context.Context, id string) {
+		message := `{"jsonrpc":"2.0","method":"notifications/cancelled","params":{"requestId":` + id + `,"reason":"test"}}`
+		if response := handleMessage(ctx, []byte(message)); response != nil {
+			t.Errorf("notifications/cancelled was answered with %#v", response)
+		}
+	}
+
+	tests := []struct {
+		name    string
+		session string // Session the cancellation comes from
+		id      string // Request id it names
+		want    bool   // Whether the running request stops
+	}{
+		{"same id", "a", "7", true},
+		{"same id, other spacing", "a", " 7 ", true},
+		{"string id", "a", `"7"`, false},
+		{"other id", "a", "8", false},
+		{"other session", "b", "7", false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctx, done := requests.start(scope("a"), json.RawMessage("7"))
+			defer done()
+
+			cancel(scope(tt.session), tt.id)
+			if stopped := ctx.Err() != nil; stopped != tt.want {
+				t.Errorf("request stopped: %v, want %v", stopped, tt.want)
+			}
+		})
+	}
+
+	// A cancelled request is not answered
+	ctx, stop := context.WithCancel(scope("a"))
+	stop()
+	if response := handleRequest(ctx, &Request{JSONRPC: "2.0", ID: json.RawMessage("1"), Method: "ping"}); response != nil {
+		t.Errorf("cancelled request was answered with %#v", response)
+	}
+
+	// Finished requests are forgotten
+	requests.mu.Lock()
+	defer requests.mu.Unlock()
+	if len(requests.cancels) != 0 {
+		t.Errorf("%d requests still tracked", len(requests.cancels))
+	}
+}